@@ -8,7 +8,18 @@ require (
 	github.com/core-go/log v0.1.3
 	github.com/core-go/service v0.3.5
 	github.com/core-go/sql v0.3.6
+	github.com/denisenkom/go-mssqldb v0.10.0
+	github.com/go-playground/validator/v10 v10.9.0
 	github.com/go-sql-driver/mysql v1.7.0
 	github.com/gorilla/mux v1.8.0
+	github.com/lib/pq v1.10.2
+	github.com/prometheus/client_golang v1.9.0
 	github.com/sirupsen/logrus v1.9.0 // indirect
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.0.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	modernc.org/sqlite v1.14.8
 )
@@ -2,80 +2,246 @@ package app
 
 import (
 	"context"
-	"github.com/core-go/health"
+	gosql "database/sql"
 	s "github.com/core-go/health/sql"
 	"github.com/core-go/sql"
+	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+	"reflect"
+	"strings"
 
+	"go-service/internal/audit"
+	"go-service/internal/auth"
 	"go-service/internal/handler"
+	"go-service/internal/lifecycle"
+	"go-service/internal/metrics"
+	"go-service/internal/migration"
+	"go-service/internal/model"
+	"go-service/internal/probe"
+	"go-service/internal/ratelimit"
+	"go-service/internal/replica"
+	"go-service/internal/repository"
+	"go-service/internal/retry"
+	"go-service/internal/search"
 	"go-service/internal/service"
-)
-
-const (
-	CreateTableUser = `
-	create table if not exists users (
-	  id varchar(40) not null,
-	  username varchar(120),
-	  email varchar(120),
-	  phone varchar(45),
-	  date_of_birth date,
-	  primary key (id)
-	)`
-
-	CreateTableMovie = `
-	create table if not exists movies (
-	  id varchar(40) not null,
-	  name varchar(120),
-	  watched tinyint,
-	  primary key (id)
-	)`
+	"go-service/internal/timeout"
+	"go-service/internal/tracing"
+	"go-service/internal/trash"
+	"go-service/internal/validation"
 )
 
 type ApplicationContext struct {
-	HealthHandler *health.Handler
-	UserHandler   *handler.UserHandler
-	MovieHandler  *handler.MovieHandler
+	Authenticator    *auth.Authenticator
+	Limiter          *ratelimit.Limiter
+	Replicas         *replica.Router
+	Timeouts         *timeout.Timeouts
+	Metrics          *metrics.Metrics
+	HealthHandler    *probe.Handler
+	AuditHandler     *handler.AuditHandler
+	AuthHandler      *handler.AuthHandler
+	BatchHandler     *handler.BatchHandler
+	UserHandler      *handler.UserHandler
+	UserBulkHandler  *handler.BulkHandler
+	MovieHandler     *handler.MovieHandler
+	MovieBulkHandler *handler.BulkHandler
+	Purger           *trash.Purger
 }
 
-func NewApp(ctx context.Context, config Config) (*ApplicationContext, error) {
-	db, err := sql.OpenByConfig(config.Sql)
+// NewApp builds the components and registers with lc whatever must be started with the server or stopped after it.
+func NewApp(ctx context.Context, config Config, lc *lifecycle.Lifecycle) (*ApplicationContext, error) {
+	if err := checkSecrets(config); err != nil {
+		return nil, err
+	}
+	metric := metrics.NewMetrics(config.Metrics)
+	db, statements, err := openDB(config, config.Sql, "default", lc, metric)
 	if err != nil {
 		return nil, err
 	}
+	replicas := replica.NewRouter(config.Replicas, db, statements)
+	for _, node := range config.Replicas.Nodes {
+		replicaDB, replicaStatements, err := openDB(config, node.Sql, node.Name, lc, metric)
+		if err != nil {
+			return nil, err
+		}
+		replicas.Add(node.Name, node.Weight, replicaDB, replicaStatements)
+	}
+	lc.Go("replicas", replicas.Run)
 
-	stmtCreate := "create database if not exists masterdata"
-	_, err = db.ExecContext(ctx, stmtCreate)
+	migrator, err := migration.NewMigrator(db, config.Migration)
 	if err != nil {
 		return nil, err
 	}
+	if config.Migration.Auto {
+		if _, err = migrator.Up(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	healthHandler := probe.NewHandler(config.Health)
+	healthHandler.AddReady(
+		s.NewSqlHealthChecker(db, "sql", healthHandler.Timeout("sql")),
+		probe.NewPoolChecker(db, config.Health.MaxPoolUsage),
+		migration.NewHealthChecker(migrator),
+		lc,
+	)
+	if len(config.Replicas.Nodes) > 0 {
+		healthHandler.AddReady(replicas)
+	}
 
-	stmtUseDB := "use masterdata"
-	_, err = db.ExecContext(ctx, stmtUseDB)
+	authenticator, err := auth.NewAuthenticator(config.Auth, config.Policy, handler.Error)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = db.ExecContext(ctx, CreateTableUser)
+	limiter, err := ratelimit.NewLimiter(config.RateLimit, db, handler.Error)
 	if err != nil {
 		return nil, err
 	}
-	_, err = db.ExecContext(ctx, CreateTableMovie)
+
+	newRepository := func(table string, modelType reflect.Type) *repository.Repository {
+		repo := repository.NewRepository(db, table, modelType)
+		repo.Statements = statements
+		repo.Replicas = replicas
+		return repo
+	}
+	retrier := retry.NewPolicy(config.Retry, metric.ObserveRetry)
+	validator := validation.NewValidator()
+	cursor := search.NewCodec(config.Search.CursorSecret)
+
+	auditRepository := newRepository("audit_logs", reflect.TypeOf(model.AuditLog{}))
+	auditor := audit.NewAuditor(auditRepository)
+	auditService := service.MeasureAuditService(service.RetryAuditService(service.NewAuditService(auditRepository, cursor), retrier), metric.ObserveQuery)
+	auditHandler := handler.NewAuditHandler(auditService, validator)
+
+	userRepository := newRepository("users", reflect.TypeOf(model.User{}))
+	userRepository.SoftDelete = config.SoftDelete.Entities["users"].Enabled
+	userService := service.MeasureUserService(service.RetryUserService(service.NewUserService(userRepository, cursor, auditor), retrier), metric.ObserveQuery)
+	userHandler := handler.NewUserHandler(userService, validator)
+	userInsert := func(ctx context.Context, item interface{}) (int64, error) {
+		return userService.Insert(ctx, item.(*model.User))
+	}
+	userBulkHandler := handler.NewBulkHandler(userRepository, userInsert, validator, config.Bulk)
+
+	movieRepository := newRepository("movies", reflect.TypeOf(model.Movie{}))
+	movieRepository.SoftDelete = config.SoftDelete.Entities["movies"].Enabled
+	movieService := service.MeasureMovieService(service.RetryMovieService(service.NewMovieService(movieRepository, cursor, auditor), retrier), metric.ObserveQuery)
+	movieHandler := handler.NewMovieHandler(movieService, validator)
+	movieInsert := func(ctx context.Context, item interface{}) (int64, error) {
+		return movieService.Insert(ctx, item.(*model.Movie))
+	}
+	movieBulkHandler := handler.NewBulkHandler(movieRepository, movieInsert, validator, config.Bulk)
+
+	signer, err := auth.NewSigner(config.Auth)
 	if err != nil {
 		return nil, err
 	}
+	credentialRepository := newRepository("user_credentials", reflect.TypeOf(model.Credential{}))
+	tokenRepository := newRepository("refresh_tokens", reflect.TypeOf(model.RefreshToken{}))
+	authService := service.MeasureAuthService(service.RetryAuthService(service.NewAuthService(userRepository, credentialRepository, tokenRepository, signer, auditor, config.Auth.Login), retrier), metric.ObserveQuery)
+	authHandler := handler.NewAuthHandler(authService, validator)
 
-	userService := service.NewUserService(db)
-	userHandler := handler.NewUserHandler(userService)
+	batchHandler := handler.NewBatchHandler(db, map[string]handler.BatchEntity{
+		"users": {
+			Repository: userRepository,
+			Insert:     userInsert,
+			Update: func(ctx context.Context, item interface{}) (int64, error) {
+				return userService.Update(ctx, item.(*model.User))
+			},
+			Patch:  userService.Patch,
+			Delete: userService.Delete,
+		},
+		"movies": {
+			Repository: movieRepository,
+			Insert:     movieInsert,
+			Update: func(ctx context.Context, item interface{}) (int64, error) {
+				return movieService.Update(ctx, item.(*model.Movie))
+			},
+			Patch:  movieService.Patch,
+			Delete: movieService.Delete,
+		},
+	}, validator, config.Batch, retrier)
 
-	movieService := service.NewMovieService(db)
-	movieHandler := handler.NewMovieHandler(movieService)
-
-	sqlChecker := s.NewHealthChecker(db)
-	healthHandler := health.NewHandler(sqlChecker)
+	purger := trash.NewPurger(config.SoftDelete, map[string]*repository.Repository{"users": userRepository, "movies": movieRepository})
+	lc.Go("purger", purger.Run)
 
 	return &ApplicationContext{
-		HealthHandler: healthHandler,
-		UserHandler:   userHandler,
-		MovieHandler:  movieHandler,
+		Authenticator:    authenticator,
+		Limiter:          limiter,
+		Replicas:         replicas,
+		Timeouts:         timeout.NewTimeouts(config.Timeout),
+		Metrics:          metric,
+		HealthHandler:    healthHandler,
+		AuditHandler:     auditHandler,
+		AuthHandler:      authHandler,
+		BatchHandler:     batchHandler,
+		UserHandler:      userHandler,
+		UserBulkHandler:  userBulkHandler,
+		MovieHandler:     movieHandler,
+		MovieBulkHandler: movieBulkHandler,
+		Purger:           purger,
 	}, nil
 }
+
+// driverName returns the registered driver for c, detecting it from the data source name when it is not configured.
+// core-go detects the legacy "mssql" and cgo "sqlite3" names, which map to the drivers linked into this binary.
+func driverName(c sql.Config) string {
+	driver := strings.TrimSpace(c.Driver)
+	if len(driver) == 0 {
+		driver = sql.Detect(c.DataSourceName)
+	}
+	switch driver {
+	case "mssql":
+		return "sqlserver"
+	case "sqlite3":
+		return "sqlite"
+	}
+	return driver
+}
+
+// dataSourceName makes the SQLite driver write times as "2006-01-02 15:04:05.999999999-07:00", which SQLite's date
+// functions read and cursors compare as text, instead of time.Time.String with its monotonic clock suffix.
+func dataSourceName(c sql.Config) string {
+	if c.Driver != "sqlite" || strings.Contains(c.DataSourceName, "_time_format=") {
+		return c.DataSourceName
+	}
+	if strings.Contains(c.DataSourceName, "?") {
+		return c.DataSourceName + "&_time_format=sqlite"
+	}
+	return c.DataSourceName + "?_time_format=sqlite"
+}
+
+// openDB opens the pool named name, with its statement cache when enabled, and closes both on shutdown.
+func openDB(config Config, c sql.Config, name string, lc *lifecycle.Lifecycle, metric *metrics.Metrics) (*gosql.DB, *repository.Statements, error) {
+	c.Driver = driverName(c)
+	c.DataSourceName = dataSourceName(c)
+	if config.Tracing.Enabled {
+		driver, err := tracing.WrapDriver(c.Driver)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Driver = driver
+	}
+	db, err := sql.OpenByConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	suffix := ""
+	if name != "default" {
+		suffix = ":" + name
+	}
+	lc.Append(lifecycle.Hook{Name: "sql" + suffix, OnStop: func(ctx context.Context) error {
+		return db.Close()
+	}})
+	metric.RegisterDB(name, db)
+	if !config.Statements.Enabled {
+		return db, nil, nil
+	}
+	statements := repository.NewStatements(db, config.Statements)
+	metric.RegisterStatements(name, statements)
+	lc.Append(lifecycle.Hook{Name: "statements" + suffix, OnStop: func(ctx context.Context) error {
+		return statements.Close()
+	}})
+	return db, statements, nil
+}
@@ -1,15 +1,79 @@
 package app
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/core-go/log"
 	mid "github.com/core-go/log/middleware"
 	sv "github.com/core-go/service"
 	"github.com/core-go/sql"
+
+	"go-service/internal/auth"
+	"go-service/internal/bulk"
+	"go-service/internal/handler"
+	"go-service/internal/lifecycle"
+	"go-service/internal/metrics"
+	"go-service/internal/migration"
+	"go-service/internal/probe"
+	"go-service/internal/ratelimit"
+	"go-service/internal/replica"
+	"go-service/internal/repository"
+	"go-service/internal/retry"
+	"go-service/internal/search"
+	"go-service/internal/timeout"
+	"go-service/internal/tracing"
+	"go-service/internal/trash"
 )
 
 type Config struct {
-	Server     sv.ServerConf `mapstructure:"server"`
-	Sql        sql.Config    `mapstructure:"sql"`
-	Log        log.Config    `mapstructure:"log"`
-	MiddleWare mid.LogConfig `mapstructure:"middleware"`
+	Server     sv.ServerConf              `mapstructure:"server"`
+	Shutdown   lifecycle.Config           `mapstructure:"shutdown"`
+	Sql        sql.Config                 `mapstructure:"sql"`
+	Replicas   replica.Config             `mapstructure:"replicas"`
+	Statements repository.StatementConfig `mapstructure:"statements"`
+	Retry      retry.Config               `mapstructure:"retry"`
+	Timeout    timeout.Config             `mapstructure:"timeout"`
+	Migration  migration.Config           `mapstructure:"migration"`
+	Health     probe.Config               `mapstructure:"health"`
+	Metrics    metrics.Config             `mapstructure:"metrics"`
+	Tracing    tracing.Config             `mapstructure:"tracing"`
+	Auth       auth.Config                `mapstructure:"auth"`
+	Policy     auth.Policy                `mapstructure:"policy"`
+	RateLimit  ratelimit.Config           `mapstructure:"rate_limit"`
+	Search     search.Config              `mapstructure:"search"`
+	Bulk       bulk.Config                `mapstructure:"bulk"`
+	Batch      handler.BatchConfig        `mapstructure:"batch"`
+	SoftDelete trash.Config               `mapstructure:"soft_delete"`
+	Log        log.Config                 `mapstructure:"log"`
+	MiddleWare mid.LogConfig              `mapstructure:"middleware"`
+}
+
+// placeholder starts every secret shipped in configs/config.yml.
+const placeholder = "change-me"
+
+// checkSecrets refuses to run with a shipped secret, so that a default deployment cannot be signed into or
+// have its cursors forged with values anyone can read.
+func checkSecrets(c Config) error {
+	if strings.HasPrefix(c.Search.CursorSecret, placeholder) {
+		return placeholderError("search.cursor_secret")
+	}
+	if !c.Auth.Enabled {
+		return nil
+	}
+	for i, k := range c.Auth.JWT.Keys {
+		if strings.HasPrefix(k.Secret, placeholder) {
+			return placeholderError(fmt.Sprintf("auth.jwt.keys[%d].secret", i))
+		}
+	}
+	for i, k := range c.Auth.APIKey.Keys {
+		if strings.HasPrefix(k.Key, placeholder) {
+			return placeholderError(fmt.Sprintf("auth.api_key.keys[%d].key", i))
+		}
+	}
+	return nil
+}
+
+func placeholderError(name string) error {
+	return fmt.Errorf("%s still has its %s placeholder value, set a secret of your own before starting the service", name, placeholder)
 }
@@ -3,6 +3,9 @@ package app
 import (
 	"context"
 	"github.com/gorilla/mux"
+
+	"go-service/internal/lifecycle"
+	"go-service/internal/metrics"
 )
 
 const (
@@ -13,17 +16,43 @@ const (
 	DELETE = "DELETE"
 )
 
-func Route(r *mux.Router, ctx context.Context, config Config) error {
-	app, err := NewApp(ctx, config)
+func Route(r *mux.Router, ctx context.Context, config Config, lc *lifecycle.Lifecycle) error {
+	app, err := NewApp(ctx, config, lc)
 	if err != nil {
 		return err
 	}
 
-	r.HandleFunc("/health", app.HealthHandler.Check).Methods(GET)
+	r.Use(app.Metrics.Handler)
+	r.Use(app.Timeouts.Handler)
+	r.Use(app.Limiter.IPHandler)
+	r.Use(app.Authenticator.Handler)
+	r.Use(app.Limiter.Handler)
+	r.Use(app.Replicas.Handler)
+
+	if config.Metrics.Enabled {
+		path := config.Metrics.Path
+		if len(path) == 0 {
+			path = metrics.DefaultPath
+		}
+		r.HandleFunc(path, app.Metrics.Serve).Methods(GET)
+	}
+	r.HandleFunc("/health", app.HealthHandler.Ready).Methods(GET)
+	r.HandleFunc("/health/live", app.HealthHandler.Live).Methods(GET)
+	r.HandleFunc("/health/ready", app.HealthHandler.Ready).Methods(GET)
+	r.HandleFunc("/batch", app.BatchHandler.Batch).Methods(POST)
+	r.HandleFunc("/audit", app.AuditHandler.Search).Methods(GET)
+	r.HandleFunc("/auth/login", app.AuthHandler.Login).Methods(POST)
+	r.HandleFunc("/auth/refresh", app.AuthHandler.Refresh).Methods(POST)
+	r.HandleFunc("/auth/logout", app.AuthHandler.Logout).Methods(POST)
 
 	userPath := "/users"
 	r.HandleFunc(userPath, app.UserHandler.All).Methods(GET)
+	r.HandleFunc(userPath+"/export", app.UserBulkHandler.Export).Methods(GET)
+	r.HandleFunc(userPath+"/import", app.UserBulkHandler.Import).Methods(POST)
+	r.HandleFunc(userPath+"/trash", app.UserHandler.Trash).Methods(GET)
 	r.HandleFunc(userPath+"/{id}", app.UserHandler.Load).Methods(GET)
+	r.HandleFunc(userPath+"/{id}/restore", app.UserHandler.Restore).Methods(POST)
+	r.HandleFunc(userPath+"/{id}/password", app.AuthHandler.ChangePassword).Methods(POST)
 	r.HandleFunc(userPath, app.UserHandler.Insert).Methods(POST)
 	r.HandleFunc(userPath+"/{id}", app.UserHandler.Update).Methods(PUT)
 	r.HandleFunc(userPath+"/{id}", app.UserHandler.Patch).Methods(PATCH)
@@ -31,8 +60,13 @@ func Route(r *mux.Router, ctx context.Context, config Config) error {
 	r.HandleFunc(userPath+"/search", app.UserHandler.Search).Methods(POST)
 	moviePath := "/movies"
 	r.HandleFunc(moviePath, app.MovieHandler.All).Methods(GET)
+	r.HandleFunc(moviePath+"/export", app.MovieBulkHandler.Export).Methods(GET)
+	r.HandleFunc(moviePath+"/import", app.MovieBulkHandler.Import).Methods(POST)
+	r.HandleFunc(moviePath+"/trash", app.MovieHandler.Trash).Methods(GET)
 	r.HandleFunc(moviePath+"/{id}", app.MovieHandler.Load).Methods(GET)
-	r.HandleFunc(moviePath+"/{id}", app.MovieHandler.Insert).Methods(PUT)
+	r.HandleFunc(moviePath+"/{id}/restore", app.MovieHandler.Restore).Methods(POST)
+	r.HandleFunc(moviePath, app.MovieHandler.Insert).Methods(POST)
+	r.HandleFunc(moviePath+"/{id}", app.MovieHandler.Update).Methods(PUT)
 	r.HandleFunc(moviePath+"/{id}", app.MovieHandler.Patch).Methods(PATCH)
 	r.HandleFunc(moviePath+"/{id}", app.MovieHandler.Delete).Methods(DELETE)
 	r.HandleFunc(moviePath+"/search", app.MovieHandler.Search).Methods(POST)
@@ -0,0 +1,206 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+type Code string
+
+const (
+	BadRequest         Code = "bad_request"
+	Validation         Code = "validation"
+	Unauthorized       Code = "unauthorized"
+	Forbidden          Code = "forbidden"
+	NotFound           Code = "not_found"
+	Duplicate          Code = "duplicate_key"
+	Conflict           Code = "conflict"
+	BadReference       Code = "bad_reference"
+	PreconditionFailed Code = "precondition_failed"
+	Locked             Code = "locked"
+	RateLimited        Code = "rate_limited"
+	Internal           Code = "internal"
+	Unavailable        Code = "unavailable"
+	Timeout            Code = "timeout"
+)
+
+var statuses = map[Code]int{
+	BadRequest:         http.StatusBadRequest,
+	Validation:         http.StatusBadRequest,
+	Unauthorized:       http.StatusUnauthorized,
+	Forbidden:          http.StatusForbidden,
+	NotFound:           http.StatusNotFound,
+	Duplicate:          http.StatusConflict,
+	Conflict:           http.StatusConflict,
+	BadReference:       http.StatusUnprocessableEntity,
+	PreconditionFailed: http.StatusPreconditionFailed,
+	Locked:             http.StatusLocked,
+	RateLimited:        http.StatusTooManyRequests,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	Timeout:            http.StatusGatewayTimeout,
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Param   string `json:"param,omitempty"`
+}
+
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func NewValidation(fields []FieldError) *Error {
+	return &Error{Code: Validation, Message: "validation failed", Fields: fields}
+}
+
+// As returns err as an *Error, classifying an expired deadline as Timeout, a canceled request as Unavailable
+// and other unknown errors as Internal.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(Timeout, "the request did not complete before its deadline", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return Wrap(Unavailable, "the request was canceled", err)
+	}
+	return Wrap(Internal, "internal server error", err)
+}
+
+func Is(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+func Status(code Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromDB translates driver errors into domain errors and leaves any other error untouched.
+func FromDB(err error) error {
+	if err == nil {
+		return nil
+	}
+	var my *mysql.MySQLError
+	if errors.As(err, &my) {
+		return fromMySQL(my, err)
+	}
+	var pg *pq.Error
+	if errors.As(err, &pg) {
+		return fromPostgres(pg, err)
+	}
+	var ms mssql.Error
+	if errors.As(err, &ms) {
+		return fromSQLServer(ms, err)
+	}
+	var lite *sqlite.Error
+	if errors.As(err, &lite) {
+		return fromSQLite(lite, err)
+	}
+	return err
+}
+
+func fromMySQL(e *mysql.MySQLError, err error) error {
+	switch e.Number {
+	case 1062:
+		return Wrap(Duplicate, "duplicate key", err)
+	case 1451:
+		return Wrap(Conflict, "row is referenced by another resource", err)
+	case 1452:
+		return Wrap(BadReference, "referenced resource does not exist", err)
+	case 1048, 1264, 1292, 1366, 1406:
+		return Wrap(Validation, e.Message, err)
+	case 3024:
+		return Wrap(Timeout, "the statement did not complete before its deadline", err)
+	}
+	return err
+}
+
+func fromPostgres(e *pq.Error, err error) error {
+	switch {
+	case e.Code == "23505":
+		return Wrap(Duplicate, "duplicate key", err)
+	case e.Code == "23503" && strings.Contains(e.Detail, "still referenced"):
+		return Wrap(Conflict, "row is referenced by another resource", err)
+	case e.Code == "23503":
+		return Wrap(BadReference, "referenced resource does not exist", err)
+	case e.Code == "23502" || e.Code == "23514" || e.Code.Class() == "22":
+		return Wrap(Validation, e.Message, err)
+	case e.Code == "57014":
+		return Wrap(Timeout, "the statement did not complete before its deadline", err)
+	}
+	return err
+}
+
+func fromSQLServer(e mssql.Error, err error) error {
+	switch e.Number {
+	case 2601, 2627:
+		return Wrap(Duplicate, "duplicate key", err)
+	case 547:
+		if strings.Contains(e.Message, "DELETE statement") {
+			return Wrap(Conflict, "row is referenced by another resource", err)
+		}
+		if strings.Contains(e.Message, "FOREIGN KEY") {
+			return Wrap(BadReference, "referenced resource does not exist", err)
+		}
+		return Wrap(Validation, e.Message, err)
+	case 220, 241, 242, 515, 2628, 8115, 8152:
+		return Wrap(Validation, e.Message, err)
+	}
+	return err
+}
+
+// fromSQLite relies on extended result codes, which the driver reports by default.
+func fromSQLite(e *sqlite.Error, err error) error {
+	switch e.Code() {
+	case 1555, 2067:
+		return Wrap(Duplicate, "duplicate key", err)
+	case 787:
+		return Wrap(BadReference, "referenced resource does not exist", err)
+	case 275, 1299:
+		return Wrap(Validation, e.Error(), err)
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go-service/internal/middleware"
+	"go-service/internal/repository"
+)
+
+const (
+	Insert   = "insert"
+	Update   = "update"
+	Patch    = "patch"
+	Delete   = "delete"
+	Restore  = "restore"
+	Password = "password"
+)
+
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Auditor writes one audit_logs row per mutation, through the repository of the audit_logs table.
+type Auditor struct {
+	repository *repository.Repository
+}
+
+func NewAuditor(repository *repository.Repository) *Auditor {
+	return &Auditor{repository: repository}
+}
+
+// Track runs write in a transaction together with the audit record of what it changed on the row with the given id.
+// Nothing is recorded when write fails or affects no row.
+func (a *Auditor) Track(ctx context.Context, repo *repository.Repository, operation string, id interface{}, write func(ctx context.Context) (int64, error)) (int64, error) {
+	var affected int64
+	err := repository.InTx(ctx, repo.DB, nil, func(ctx context.Context) error {
+		var before, after interface{}
+		var err error
+		if operation != Insert {
+			if before, err = load(ctx, repo, id); err != nil {
+				return err
+			}
+		}
+		if affected, err = write(ctx); err != nil || affected <= 0 {
+			return err
+		}
+		if after, err = load(ctx, repo, id); err != nil {
+			return err
+		}
+		return a.Log(ctx, repo.Table, id, operation, before, after)
+	})
+	return affected, err
+}
+
+func (a *Auditor) Log(ctx context.Context, entity string, id interface{}, operation string, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	var actor, requestId interface{}
+	if s, ok := repository.ActorFromContext(ctx); ok {
+		actor = s
+	}
+	if s := middleware.GetRequestId(ctx); len(s) > 0 {
+		requestId = s
+	}
+	columns := []string{"entity", "entity_id", "operation", "actor", "request_id", "changes", "created_at"}
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = a.repository.BuildParam(i + 1)
+	}
+	query := fmt.Sprintf("insert into %s (%s) values (%s)", a.repository.Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	_, err = a.repository.Exec(ctx, query, entity, key(id), operation, actor, requestId, string(data), time.Now())
+	return err
+}
+
+// Diff compares the json forms of before and after, either of which may be nil, and returns the changed fields by json name.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	old, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string]Change)
+	for name, value := range old {
+		if v, ok := current[name]; !ok || !reflect.DeepEqual(value, v) {
+			changes[name] = Change{Old: value, New: v}
+		}
+	}
+	for name, value := range current {
+		if _, ok := old[name]; !ok {
+			changes[name] = Change{New: value}
+		}
+	}
+	return changes, nil
+}
+
+func toMap(item interface{}) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if item == nil {
+		return m, nil
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// load returns the row with the given id, deleted or not, or nil when there is none.
+func load(ctx context.Context, repo *repository.Repository, id interface{}) (interface{}, error) {
+	item := reflect.New(repo.Metadata.Type).Interface()
+	ok, err := repo.LoadForUpdate(ctx, id, item)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return item, nil
+}
+
+func key(id interface{}) string {
+	if values, ok := id.([]interface{}); ok {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = fmt.Sprint(v)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(id)
+}
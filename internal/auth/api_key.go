@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+const DefaultAPIKeyHeader = "X-API-Key"
+
+type APIKey struct {
+	Name  string   `mapstructure:"name"`
+	Key   string   `mapstructure:"key"`
+	Roles []string `mapstructure:"roles"`
+}
+
+type APIKeyConfig struct {
+	Header string   `mapstructure:"header"`
+	Keys   []APIKey `mapstructure:"keys"`
+}
+
+type apiKeys struct {
+	keys   []APIKey
+	hashes [][sha256.Size]byte
+}
+
+func newAPIKeys(keys []APIKey) *apiKeys {
+	a := &apiKeys{keys: keys, hashes: make([][sha256.Size]byte, len(keys))}
+	for i, k := range keys {
+		a.hashes[i] = sha256.Sum256([]byte(k.Key))
+	}
+	return a
+}
+
+// find compares hashes in constant time and checks every key, so the response time does not reveal which key came close.
+func (a *apiKeys) find(key string) *Principal {
+	hash := sha256.Sum256([]byte(key))
+	var found *Principal
+	for i, h := range a.hashes {
+		if subtle.ConstantTimeCompare(hash[:], h[:]) == 1 && len(a.keys[i].Key) > 0 {
+			found = &Principal{Subject: a.keys[i].Name, Scheme: SchemeAPIKey, Roles: a.keys[i].Roles}
+		}
+	}
+	return found
+}
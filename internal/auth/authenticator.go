@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/search"
+)
+
+type Config struct {
+	Enabled bool         `mapstructure:"enabled"`
+	Exempt  []string     `mapstructure:"exempt"`
+	JWT     JWTConfig    `mapstructure:"jwt"`
+	APIKey  APIKeyConfig `mapstructure:"api_key"`
+	Login   LoginConfig  `mapstructure:"login"`
+}
+
+// Authenticator is the router middleware that resolves the Principal of each request
+// from a Bearer JWT or a static API key, then applies the role policy to it.
+type Authenticator struct {
+	enabled  bool
+	policy   Policy
+	exempt   []exemption
+	verifier *Verifier
+	apiKeys  *apiKeys
+	header   string
+	Error    func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type exemption struct {
+	method string
+	path   string
+	prefix bool
+}
+
+func NewAuthenticator(c Config, policy Policy, onError func(w http.ResponseWriter, r *http.Request, err error)) (*Authenticator, error) {
+	if policy.Enabled && !c.Enabled {
+		return nil, errors.New("the role policy needs auth to be enabled")
+	}
+	a := &Authenticator{enabled: c.Enabled, policy: policy, apiKeys: newAPIKeys(c.APIKey.Keys), header: c.APIKey.Header, Error: onError}
+	if len(a.header) == 0 {
+		a.header = DefaultAPIKeyHeader
+	}
+	if !c.Enabled {
+		return a, nil
+	}
+	// A missing configs/policy.yml is ignored when the config is loaded, and would let every principal do anything.
+	if len(policy.Rules) == 0 {
+		return nil, errors.New("auth is enabled but the role policy has no rules, check that configs/policy.yml is present")
+	}
+	verifier, err := NewVerifier(c.JWT)
+	if err != nil {
+		return nil, err
+	}
+	if len(verifier.keys) == 0 && len(c.APIKey.Keys) == 0 {
+		return nil, errors.New("auth is enabled but no JWT key or API key is configured")
+	}
+	a.verifier = verifier
+	for _, e := range c.Exempt {
+		a.exempt = append(a.exempt, parseExemption(e))
+	}
+	return a, nil
+}
+
+// parseExemption reads "/health", "GET /movies" or "/public/*"; a trailing "/*" exempts the whole subtree.
+func parseExemption(s string) exemption {
+	var e exemption
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, " "); i > 0 {
+		e.method = strings.ToUpper(s[:i])
+		s = strings.TrimSpace(s[i+1:])
+	}
+	if strings.HasSuffix(s, "/*") {
+		e.prefix = true
+		s = strings.TrimSuffix(s, "*")
+	}
+	e.path = s
+	return e
+}
+
+func (a *Authenticator) isExempt(r *http.Request) bool {
+	for _, e := range a.exempt {
+		if len(e.method) > 0 && e.method != r.Method {
+			continue
+		}
+		if r.URL.Path == e.path || (e.prefix && (strings.HasPrefix(r.URL.Path, e.path) || r.URL.Path+"/" == e.path)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled || a.isExempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="go-service"`)
+			a.Error(w, r, err)
+			return
+		}
+		r = r.WithContext(WithPrincipal(r.Context(), principal))
+		if !a.policy.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		permission, ok := a.policy.Authorize(principal, r.Method, r.URL.Path)
+		if !ok {
+			a.Error(w, r, apperror.Newf(apperror.Forbidden, "%s may not %s %s", principal.Subject, r.Method, r.URL.Path))
+			return
+		}
+		if err = checkRequest(r, permission.ReadOnly); err != nil {
+			a.Error(w, r, err)
+			return
+		}
+		if len(permission.Masked) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.WithContext(search.WithHidden(r.Context(), permission.Masked))
+		mw := &maskingWriter{ResponseWriter: w, r: r, masked: permission.Masked, onError: a.Error}
+		next.ServeHTTP(mw, r)
+		mw.finish()
+	})
+}
+
+// Authenticate accepts "Authorization: Bearer <jwt>" or the API key header.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if authorization := r.Header.Get("Authorization"); len(authorization) > 0 {
+		const bearer = "bearer "
+		if len(authorization) <= len(bearer) || !strings.EqualFold(authorization[:len(bearer)], bearer) {
+			return nil, apperror.New(apperror.Unauthorized, "unsupported authorization scheme, use Bearer")
+		}
+		principal, err := a.verifier.Verify(strings.TrimSpace(authorization[len(bearer):]))
+		if err != nil {
+			return nil, apperror.New(apperror.Unauthorized, err.Error())
+		}
+		return principal, nil
+	}
+	if key := r.Header.Get(a.header); len(key) > 0 {
+		if principal := a.apiKeys.find(key); principal != nil {
+			return principal, nil
+		}
+		return nil, apperror.New(apperror.Unauthorized, "invalid API key")
+	}
+	return nil, apperror.New(apperror.Unauthorized, "authentication required")
+}
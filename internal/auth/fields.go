@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go-service/internal/apperror"
+)
+
+// checkRequest refuses a JSON, NDJSON or CSV body that sets a read only field, at any depth,
+// so that batch operations and imports are covered as well as single writes.
+func checkRequest(r *http.Request, readOnly map[string]bool) error {
+	if len(readOnly) == 0 || r.Body == nil || (r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch) {
+		return nil
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return apperror.Wrap(apperror.BadRequest, "cannot read body", err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	found := make(map[string]bool)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		header, err := csv.NewReader(bytes.NewReader(data)).Read()
+		if err != nil && err != io.EOF {
+			return nil
+		}
+		for _, name := range header {
+			name = strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")
+			if readOnly[name] {
+				found[name] = true
+			}
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var v interface{}
+			if err := decoder.Decode(&v); err != nil {
+				// invalid bodies are left to the handler to report
+				break
+			}
+			walk(v, func(m map[string]interface{}) {
+				for name := range m {
+					if readOnly[name] {
+						found[name] = true
+					}
+				}
+			})
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	fields := make([]apperror.FieldError, 0, len(found))
+	for name := range found {
+		fields = append(fields, apperror.FieldError{Field: name, Code: "read_only", Message: "you may not change " + name})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &apperror.Error{Code: apperror.Forbidden, Message: "the request sets fields you may not change", Fields: fields}
+}
+
+func walk(v interface{}, f func(map[string]interface{})) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		f(t)
+		for _, item := range t {
+			walk(item, f)
+		}
+	case []interface{}:
+		for _, item := range t {
+			walk(item, f)
+		}
+	}
+}
+
+var errMaskedExport = errors.New("response cannot be filtered")
+
+// maskingWriter drops masked fields from successful JSON responses. It buffers those responses to rewrite them,
+// and refuses other successful responses, such as CSV and NDJSON exports, which it cannot filter.
+type maskingWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	masked  map[string]bool
+	onError func(w http.ResponseWriter, r *http.Request, err error)
+	status  int
+	buffer  *bytes.Buffer
+	refused bool
+}
+
+func (w *maskingWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < 200 || status >= 300 || status == http.StatusNoContent {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	contentType := w.Header().Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") {
+		w.buffer = &bytes.Buffer{}
+		return
+	}
+	w.refuse()
+}
+
+func (w *maskingWriter) refuse() {
+	w.refused = true
+	w.Header().Del("Content-Disposition")
+	w.Header().Del("Content-Length")
+	w.onError(w.ResponseWriter, w.r, apperror.New(apperror.Forbidden, "this response holds fields you may not read"))
+}
+
+func (w *maskingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.refused {
+		return 0, errMaskedExport
+	}
+	if w.buffer != nil {
+		return w.buffer.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// finish writes the buffered response without its masked fields. A body that is not exactly one JSON value,
+// such as one cut off by a failed export, is refused rather than sent unfiltered.
+func (w *maskingWriter) finish() {
+	if w.buffer == nil {
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(w.buffer.Bytes()))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		w.refuse()
+		return
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		w.refuse()
+		return
+	}
+	walk(v, func(m map[string]interface{}) {
+		for name := range w.masked {
+			delete(m, name)
+		}
+	})
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.refuse()
+		return
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(append(data, '\n'))
+}
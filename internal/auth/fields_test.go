@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-service/internal/search"
+)
+
+func TestMaskingWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantStatus  int
+		want        string
+	}{
+		{"masks every depth", 200, "application/json", `{"list":[{"id":"a","email":"a@b.co","phone":"1"}],"total":1}`, 200, `{"list":[{"id":"a"}],"total":1}` + "\n"},
+		{"keeps numbers", 200, "application/json", `[{"id":"a","version":12345678901234567890}]`, 200, `[{"id":"a","version":12345678901234567890}]` + "\n"},
+		{"refuses a cut off body", 200, "application/json", `[{"id":"a","email":"a@b.co"},{"id":"b","em`, 403, ""},
+		{"refuses trailing values", 200, "application/json", `{"id":"a"}` + "\n" + `{"id":"b","email":"b@b.co"}`, 403, ""},
+		{"refuses csv", 200, "text/csv", "id,email\na,a@b.co\n", 403, ""},
+		{"passes errors through", 404, "application/problem+json", `{"status":404}`, 404, `{"status":404}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			refused := 0
+			w := &maskingWriter{
+				ResponseWriter: rec,
+				r:              httptest.NewRequest(http.MethodGet, "/users", nil),
+				masked:         map[string]bool{"email": true, "phone": true},
+				onError: func(w http.ResponseWriter, r *http.Request, err error) {
+					refused++
+					w.WriteHeader(http.StatusForbidden)
+				},
+			}
+			w.Header().Set("Content-Type", tt.contentType)
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+			w.finish()
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				if refused != 1 || strings.Contains(rec.Body.String(), "@") {
+					t.Fatalf("got body %q after %d refusals", rec.Body.String(), refused)
+				}
+				return
+			}
+			if rec.Body.String() != tt.want {
+				t.Fatalf("got body %q, want %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerHidesMaskedFieldsFromSearch(t *testing.T) {
+	policy := Policy{Enabled: true, Rules: []Rule{
+		{Roles: []string{"admin"}, Resources: []string{"*"}, Methods: []string{"*"}},
+		{Roles: []string{"viewer"}, Resources: []string{"/users"}, Methods: []string{"GET", "POST"}, Mask: []string{"email", "phone"}},
+	}}
+	c := Config{Enabled: true, APIKey: APIKeyConfig{Keys: []APIKey{
+		{Name: "admin", Key: "admin-key", Roles: []string{"admin"}},
+		{Name: "viewer", Key: "viewer-key", Roles: []string{"viewer"}},
+	}}}
+	a, err := NewAuthenticator(c, policy, func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key    string
+		hidden []string
+	}{
+		{"admin-key", nil},
+		{"viewer-key", []string{"email", "phone"}},
+	}
+	for _, tt := range tests {
+		var hidden []string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hidden = keys(search.Hidden(r.Context()))
+			w.WriteHeader(http.StatusNoContent)
+		})
+		r := httptest.NewRequest(http.MethodPost, "/users/search", strings.NewReader(`{"sort":"email","limit":1}`))
+		r.Header.Set(DefaultAPIKeyHeader, tt.key)
+		rec := httptest.NewRecorder()
+		a.Handler(next).ServeHTTP(rec, r)
+		if rec.Code != http.StatusNoContent || !reflect.DeepEqual(hidden, tt.hidden) {
+			t.Fatalf("%s: got status %d and hidden fields %v", tt.key, rec.Code, hidden)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+type JWTConfig struct {
+	JWKSFile   string `mapstructure:"jwks_file"`
+	Keys       []Key  `mapstructure:"keys"`
+	Issuer     string `mapstructure:"issuer"`
+	Audience   string `mapstructure:"audience"`
+	Leeway     int64  `mapstructure:"leeway"`
+	RolesClaim string `mapstructure:"roles_claim"`
+}
+
+// Verifier checks the signature and the registered claims of HS256 and RS256 tokens.
+type Verifier struct {
+	keys       []verificationKey
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	rolesClaim string
+	now        func() time.Time
+}
+
+func NewVerifier(c JWTConfig) (*Verifier, error) {
+	var keys []verificationKey
+	if len(c.JWKSFile) > 0 {
+		var err error
+		if keys, err = LoadJWKS(c.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range c.Keys {
+		key, err := parseKey(k)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	rolesClaim := c.RolesClaim
+	if len(rolesClaim) == 0 {
+		rolesClaim = "roles"
+	}
+	return &Verifier{keys: keys, issuer: c.Issuer, audience: c.Audience, leeway: time.Duration(c.Leeway) * time.Second, rolesClaim: rolesClaim, now: time.Now}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify returns the principal of a valid token. Errors only say why the token was rejected, never anything about the keys.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	if !v.verifySignature(h, parts[0]+"."+parts[1], signature) {
+		return nil, errors.New("invalid token signature")
+	}
+	var claims map[string]interface{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	if err = v.validate(claims); err != nil {
+		return nil, err
+	}
+	sub, _ := claims["sub"].(string)
+	if len(sub) == 0 {
+		return nil, errors.New("token has no subject")
+	}
+	return &Principal{Subject: sub, Scheme: SchemeJWT, Roles: stringList(claims[v.rolesClaim]), Claims: claims}, nil
+}
+
+// verifySignature only uses keys of the algorithm named in the header, so an RSA public key is never used as an HMAC secret.
+func (v *Verifier) verifySignature(h header, signed string, signature []byte) bool {
+	if h.Alg != HS256 && h.Alg != RS256 {
+		return false
+	}
+	for _, k := range v.keys {
+		if k.alg != h.Alg || (len(h.Kid) > 0 && k.kid != h.Kid) {
+			continue
+		}
+		switch k.alg {
+		case HS256:
+			mac := hmac.New(sha256.New, k.secret)
+			mac.Write([]byte(signed))
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return true
+			}
+		case RS256:
+			hash := sha256.Sum256([]byte(signed))
+			if rsa.VerifyPKCS1v15(k.public, crypto.SHA256, hash[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *Verifier) validate(claims map[string]interface{}) error {
+	now := v.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(exp.Add(v.leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.leeway).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+	if len(v.issuer) > 0 {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return errors.New("token issuer not accepted")
+		}
+	}
+	if len(v.audience) > 0 && !contains(stringList(claims["aud"]), v.audience) {
+		return errors.New("token audience not accepted")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// stringList reads a claim that is either a string, a space separated string or an array of strings.
+func stringList(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []interface{}:
+		list := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
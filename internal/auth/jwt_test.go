@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func encode(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func hs256(t *testing.T, header map[string]interface{}, claims map[string]interface{}, secret []byte) string {
+	signed := encode(t, header) + "." + encode(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func rs256(t *testing.T, header map[string]interface{}, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	signed := encode(t, header) + "." + encode(t, claims)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func claims(extra map[string]interface{}) map[string]interface{} {
+	c := map[string]interface{}{
+		"sub":   "tony",
+		"exp":   now.Add(time.Minute).Unix(),
+		"iss":   "https://login.example.com/",
+		"aud":   "go-service",
+		"roles": []string{"admin", "support"},
+	}
+	for k, v := range extra {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestVerify(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	v, err := NewVerifier(JWTConfig{
+		Keys: []Key{
+			{Kid: "hs", Alg: HS256, Secret: string(secret)},
+			{Kid: "rs", Alg: RS256, PublicKey: string(publicPEM)},
+		},
+		Issuer:   "https://login.example.com/",
+		Audience: "go-service",
+		Leeway:   30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.now = func() time.Time { return now }
+
+	hs := map[string]interface{}{"alg": HS256, "kid": "hs"}
+	rs := map[string]interface{}{"alg": RS256, "kid": "rs"}
+	valid := claims(nil)
+
+	tests := []struct {
+		name  string
+		token string
+		err   string
+	}{
+		{"hs256", hs256(t, hs, valid, secret), ""},
+		{"rs256", rs256(t, rs, valid, private), ""},
+		{"no kid tries every key of the alg", hs256(t, map[string]interface{}{"alg": HS256}, valid, secret), ""},
+		{"audience in a list", hs256(t, hs, claims(map[string]interface{}{"aud": []string{"other", "go-service"}}), secret), ""},
+		{"expired within leeway", hs256(t, hs, claims(map[string]interface{}{"exp": now.Add(-20 * time.Second).Unix()}), secret), ""},
+		{"wrong secret", hs256(t, hs, valid, []byte("another secret")), "invalid token signature"},
+		{"kid of another key", hs256(t, map[string]interface{}{"alg": HS256, "kid": "rs"}, valid, secret), "invalid token signature"},
+		{"public key used as hmac secret", hs256(t, map[string]interface{}{"alg": HS256, "kid": "rs"}, valid, publicPEM), "invalid token signature"},
+		{"public key used as hmac secret without kid", hs256(t, map[string]interface{}{"alg": HS256}, valid, publicPEM), "invalid token signature"},
+		{"alg none", encode(t, map[string]interface{}{"alg": "none"}) + "." + encode(t, valid) + ".", "invalid token signature"},
+		{"rs256 header on hmac signature", hs256(t, rs, valid, secret), "invalid token signature"},
+		{"tampered claims", tamper(hs256(t, hs, valid, secret), encode(t, claims(map[string]interface{}{"sub": "root"}))), "invalid token signature"},
+		{"expired", hs256(t, hs, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}), secret), "token expired"},
+		{"no expiry", hs256(t, hs, claims(map[string]interface{}{"exp": nil}), secret), "token has no expiry"},
+		{"not valid yet", hs256(t, hs, claims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), secret), "token not valid yet"},
+		{"other issuer", hs256(t, hs, claims(map[string]interface{}{"iss": "https://evil.example.com/"}), secret), "token issuer not accepted"},
+		{"other audience", hs256(t, hs, claims(map[string]interface{}{"aud": "other"}), secret), "token audience not accepted"},
+		{"no subject", hs256(t, hs, claims(map[string]interface{}{"sub": nil}), secret), "token has no subject"},
+		{"two segments", "a.b", "malformed token"},
+		{"bad header", "!!.e30.c2ln", "malformed token header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if len(tt.err) > 0 {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if p.Subject != "tony" || p.Scheme != SchemeJWT || len(p.Roles) != 2 || p.Roles[0] != "admin" {
+				t.Fatalf("got principal %+v", p)
+			}
+		})
+	}
+}
+
+// tamper replaces the claims of token, keeping its header and signature.
+func tamper(token string, claims string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + claims + "." + parts[2]
+}
+
+func TestSignerIsAcceptedByVerifier(t *testing.T) {
+	c := Config{
+		JWT:   JWTConfig{Keys: []Key{{Kid: "local", Alg: HS256, Secret: "0123456789abcdef"}}, Issuer: "go-service", RolesClaim: "groups"},
+		Login: LoginConfig{Kid: "local"},
+	}
+	signer, err := NewSigner(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signer.Sign("tony", []string{"user"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewVerifier(c.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := v.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Subject != "tony" || len(p.Roles) != 1 || p.Roles[0] != "user" {
+		t.Fatalf("got principal %+v", p)
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []Key
+		kid   string
+		ok    bool
+		empty bool
+	}{
+		{"login off", nil, "", true, true},
+		{"hs256 key", []Key{{Kid: "a", Alg: HS256, Secret: "s"}}, "a", true, false},
+		{"unknown kid", []Key{{Kid: "a", Alg: HS256, Secret: "s"}}, "b", false, false},
+		{"rs256 key", []Key{{Kid: "a", Alg: RS256, PublicKey: "pem"}}, "a", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSigner(Config{JWT: JWTConfig{Keys: tt.keys}, Login: LoginConfig{Kid: tt.kid}})
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v", err)
+			}
+			if tt.ok && (s == nil) != tt.empty {
+				t.Fatalf("got signer %v", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// Key is a configured verification key: Secret for HS256, or a PEM public key, inline or in a file, for RS256.
+type Key struct {
+	Kid           string `mapstructure:"kid"`
+	Alg           string `mapstructure:"alg"`
+	Secret        string `mapstructure:"secret"`
+	PublicKey     string `mapstructure:"public_key"`
+	PublicKeyFile string `mapstructure:"public_key_file"`
+}
+
+type verificationKey struct {
+	kid    string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+func parseKey(k Key) (verificationKey, error) {
+	switch k.Alg {
+	case HS256:
+		if len(k.Secret) == 0 {
+			return verificationKey{}, fmt.Errorf("key %s: HS256 needs a secret", k.Kid)
+		}
+		return verificationKey{kid: k.Kid, alg: HS256, secret: []byte(k.Secret)}, nil
+	case RS256:
+		data := []byte(k.PublicKey)
+		if len(k.PublicKeyFile) > 0 {
+			var err error
+			if data, err = ioutil.ReadFile(k.PublicKeyFile); err != nil {
+				return verificationKey{}, err
+			}
+		}
+		public, err := parsePublicKey(data)
+		if err != nil {
+			return verificationKey{}, fmt.Errorf("key %s: %v", k.Kid, err)
+		}
+		return verificationKey{kid: k.Kid, alg: RS256, public: public}, nil
+	}
+	return verificationKey{}, fmt.Errorf("key %s: unsupported alg %s, use HS256 or RS256", k.Kid, k.Alg)
+}
+
+func parsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+		return nil, errors.New("certificate does not hold an RSA key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return public, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// LoadJWKS reads the RSA ("kty": "RSA") and symmetric ("kty": "oct") signing keys of a JSON Web Key Set file.
+func LoadJWKS(path string) ([]verificationKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS %s: %v", path, err)
+	}
+	var keys []verificationKey
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, er1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, er2 := base64.RawURLEncoding.DecodeString(k.E)
+			if er1 != nil || er2 != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("invalid RSA key %s in %s", k.Kid, path)
+			}
+			public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: RS256, public: public})
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("invalid symmetric key %s in %s", k.Kid, path)
+			}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: HS256, secret: secret})
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const Any = "*"
+
+// Rule grants its roles the methods on every path under its resources.
+// Mask fields are left out of responses and refused in requests; ReadOnly fields are only refused in requests.
+type Rule struct {
+	Roles     []string `mapstructure:"roles"`
+	Resources []string `mapstructure:"resources"`
+	Methods   []string `mapstructure:"methods"`
+	Mask      []string `mapstructure:"mask"`
+	ReadOnly  []string `mapstructure:"read_only"`
+}
+
+type Policy struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Rules   []Rule `mapstructure:"rules"`
+}
+
+// Permission is what the matching rules allow on one request. Roles add up: a field stays restricted
+// only when every matching rule restricts it.
+type Permission struct {
+	Masked   map[string]bool
+	ReadOnly map[string]bool
+}
+
+// Authorize returns the permission of p for the request, or false when no rule allows it.
+func (policy Policy) Authorize(p *Principal, method string, path string) (*Permission, bool) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	var permission *Permission
+	for _, rule := range policy.Rules {
+		if !rule.matches(p, method, path) {
+			continue
+		}
+		masked := set(rule.Mask)
+		readOnly := set(rule.Mask, rule.ReadOnly)
+		if permission == nil {
+			permission = &Permission{Masked: masked, ReadOnly: readOnly}
+			continue
+		}
+		intersect(permission.Masked, masked)
+		intersect(permission.ReadOnly, readOnly)
+	}
+	return permission, permission != nil
+}
+
+func (rule Rule) matches(p *Principal, method string, path string) bool {
+	return matchAny(rule.Methods, func(m string) bool { return strings.EqualFold(m, method) }) &&
+		matchAny(rule.Roles, func(role string) bool {
+			for _, r := range p.Roles {
+				if strings.EqualFold(r, role) {
+					return true
+				}
+			}
+			return false
+		}) &&
+		matchAny(rule.Resources, func(resource string) bool {
+			return MatchPath(resource, path)
+		})
+}
+
+// MatchPath reports whether path is resource or below it. A "*" segment in resource matches any one segment,
+// so "/users/*/password" covers the password of every user.
+func MatchPath(resource string, path string) bool {
+	patterns := strings.Split(strings.Trim(resource, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < len(patterns) {
+		return false
+	}
+	for i, p := range patterns {
+		if p != Any && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(list []string, match func(string) bool) bool {
+	for _, s := range list {
+		if s == Any || match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func set(lists ...[]string) map[string]bool {
+	m := make(map[string]bool)
+	for _, list := range lists {
+		for _, s := range list {
+			m[s] = true
+		}
+	}
+	return m
+}
+
+func intersect(m map[string]bool, other map[string]bool) {
+	for k := range m {
+		if !other[k] {
+			delete(m, k)
+		}
+	}
+}
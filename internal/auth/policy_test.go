@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		resource string
+		path     string
+		want     bool
+	}{
+		{"/users", "/users", true},
+		{"/users", "/users/", true},
+		{"/users", "/users/ironman", true},
+		{"/users", "/users/ironman/password", true},
+		{"/users", "/usersx", false},
+		{"/users", "/movies", false},
+		{"/users", "/", false},
+		{"/users/*/password", "/users/ironman/password", true},
+		{"/users/*/password", "/users/ironman", false},
+		{"/users/*/password", "/users/ironman/restore", false},
+		{"/*/search", "/movies/search", true},
+		{"/*/search", "/search", false},
+		{"*", "/anything/at/all", true},
+		{"/users/ironman", "/users/Ironman", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.resource, tt.path); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.resource, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := Policy{Enabled: true, Rules: []Rule{
+		{Roles: []string{"admin"}, Resources: []string{"*"}, Methods: []string{"*"}},
+		{Roles: []string{"support"}, Resources: []string{"/users", "/movies"}, Methods: []string{"GET"}},
+		{Roles: []string{"support"}, Resources: []string{"/users"}, Methods: []string{"PATCH"}, ReadOnly: []string{"email", "phone"}},
+		{Roles: []string{"viewer"}, Resources: []string{"/users"}, Methods: []string{"GET"}, Mask: []string{"email", "phone", "dateOfBirth"}},
+		{Roles: []string{"auditor"}, Resources: []string{"/users"}, Methods: []string{"GET"}, Mask: []string{"phone"}},
+		{Roles: []string{"*"}, Resources: []string{"/users/*/password"}, Methods: []string{"POST"}},
+	}}
+	tests := []struct {
+		name     string
+		roles    []string
+		method   string
+		path     string
+		allowed  bool
+		masked   []string
+		readOnly []string
+	}{
+		{"admin anything", []string{"admin"}, "DELETE", "/movies/heat", true, nil, nil},
+		{"support reads", []string{"support"}, "GET", "/movies/heat", true, nil, nil},
+		{"head is a get", []string{"support"}, "HEAD", "/movies", true, nil, nil},
+		{"support cannot delete", []string{"support"}, "DELETE", "/users/ironman", false, nil, nil},
+		{"method case", []string{"support"}, "patch", "/users/ironman", true, nil, []string{"email", "phone"}},
+		{"viewer masked", []string{"viewer"}, "GET", "/users", true, []string{"dateOfBirth", "email", "phone"}, []string{"dateOfBirth", "email", "phone"}},
+		{"viewer and support unmask", []string{"viewer", "support"}, "GET", "/users", true, nil, nil},
+		{"viewer and auditor keep the common mask", []string{"viewer", "auditor"}, "GET", "/users", true, []string{"phone"}, []string{"phone"}},
+		{"viewer cannot read movies", []string{"viewer"}, "GET", "/movies", false, nil, nil},
+		{"any role changes passwords", []string{"user"}, "POST", "/users/ironman/password", true, nil, nil},
+		{"no role", nil, "GET", "/users", false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permission, ok := policy.Authorize(&Principal{Subject: "tony", Roles: tt.roles}, tt.method, tt.path)
+			if ok != tt.allowed {
+				t.Fatalf("got allowed %v", ok)
+			}
+			if !ok {
+				return
+			}
+			if got := keys(permission.Masked); !reflect.DeepEqual(got, tt.masked) {
+				t.Errorf("got masked %v, want %v", got, tt.masked)
+			}
+			if got := keys(permission.ReadOnly); !reflect.DeepEqual(got, tt.readOnly) {
+				t.Errorf("got read only %v, want %v", got, tt.readOnly)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorNeedsPolicyRules(t *testing.T) {
+	c := Config{Enabled: true, APIKey: APIKeyConfig{Keys: []APIKey{{Name: "admin", Key: "k", Roles: []string{"admin"}}}}}
+	if _, err := NewAuthenticator(c, Policy{}, nil); err == nil {
+		t.Fatal("auth without policy rules was accepted")
+	}
+	if _, err := NewAuthenticator(c, Policy{Enabled: true, Rules: []Rule{{Roles: []string{"admin"}}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAuthenticator(Config{}, Policy{}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keys(m map[string]bool) []string {
+	var list []string
+	for _, name := range []string{"dateOfBirth", "email", "phone"} {
+		if m[name] {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+func TestIsExempt(t *testing.T) {
+	a := &Authenticator{}
+	for _, e := range []string{"/health", "/health/*", "GET /metrics", "post /auth/login"} {
+		a.exempt = append(a.exempt, parseExemption(e))
+	}
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{"GET", "/health", true},
+		{"GET", "/health/", true},
+		{"GET", "/health/live", true},
+		{"GET", "/health/ready", true},
+		{"GET", "/healthz", false},
+		{"GET", "/metrics", true},
+		{"POST", "/metrics", false},
+		{"GET", "/metrics/x", false},
+		{"POST", "/auth/login", true},
+		{"POST", "/auth/refresh", false},
+		{"GET", "/users", false},
+	}
+	for _, tt := range tests {
+		if got := a.isExempt(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s: got exempt %v", tt.method, tt.path, got)
+		}
+	}
+}
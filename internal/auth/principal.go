@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+
+	"go-service/internal/repository"
+)
+
+const (
+	SchemeJWT    = "jwt"
+	SchemeAPIKey = "api_key"
+
+	// UserId is the context key core-go/log reads when "userId" is listed in log.fields.
+	UserId = "userId"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string                 `json:"subject"`
+	Scheme  string                 `json:"scheme"`
+	Roles   []string               `json:"roles,omitempty"`
+	Claims  map[string]interface{} `json:"claims,omitempty"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores p in the context, and its subject as the actor of the repository writes done with it.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey{}, p)
+	ctx = context.WithValue(ctx, UserId, p.Subject)
+	return repository.WithActor(ctx, p.Subject)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LoginConfig drives the tokens issued by POST /auth/login. Kid names the HS256 key of auth.jwt.keys that signs them;
+// TTLs and Lockout are in seconds.
+type LoginConfig struct {
+	Kid         string   `mapstructure:"kid"`
+	AccessTTL   int64    `mapstructure:"access_ttl"`
+	RefreshTTL  int64    `mapstructure:"refresh_ttl"`
+	Roles       []string `mapstructure:"roles"`
+	MaxAttempts int      `mapstructure:"max_attempts"`
+	Lockout     int64    `mapstructure:"lockout"`
+	ResetRoles  []string `mapstructure:"reset_roles"`
+}
+
+// Signer issues HS256 access tokens that the Verifier of the same config accepts.
+type Signer struct {
+	kid        string
+	secret     []byte
+	issuer     string
+	audience   string
+	rolesClaim string
+}
+
+// NewSigner returns nil when no login key is configured.
+func NewSigner(c Config) (*Signer, error) {
+	if len(c.Login.Kid) == 0 {
+		return nil, nil
+	}
+	for _, k := range c.JWT.Keys {
+		if k.Kid != c.Login.Kid {
+			continue
+		}
+		if k.Alg != HS256 || len(k.Secret) == 0 {
+			return nil, fmt.Errorf("login key %s must be an HS256 key with a secret", k.Kid)
+		}
+		rolesClaim := c.JWT.RolesClaim
+		if len(rolesClaim) == 0 {
+			rolesClaim = "roles"
+		}
+		return &Signer{kid: k.Kid, secret: []byte(k.Secret), issuer: c.JWT.Issuer, audience: c.JWT.Audience, rolesClaim: rolesClaim}, nil
+	}
+	return nil, fmt.Errorf("login key %s not found in auth.jwt.keys", c.Login.Kid)
+}
+
+func (s *Signer) Sign(subject string, roles []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := map[string]interface{}{"sub": subject, "iat": now.Unix(), "exp": now.Add(ttl).Unix()}
+	if len(roles) > 0 {
+		claims[s.rolesClaim] = roles
+	}
+	if len(s.issuer) > 0 {
+		claims["iss"] = s.issuer
+	}
+	if len(s.audience) > 0 {
+		claims["aud"] = s.audience
+	}
+	header, err := json.Marshal(map[string]string{"alg": HS256, "typ": "JWT", "kid": s.kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func HasRole(p *Principal, roles []string) bool {
+	for _, r := range p.Roles {
+		if contains(roles, r) {
+			return true
+		}
+	}
+	return false
+}
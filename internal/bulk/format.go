@@ -0,0 +1,67 @@
+package bulk
+
+import (
+	"mime"
+	"strings"
+
+	"go-service/internal/apperror"
+)
+
+type Format string
+
+const (
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+)
+
+var contentTypes = map[Format]string{
+	JSON:   "application/json",
+	NDJSON: "application/x-ndjson",
+	CSV:    "text/csv",
+}
+
+var formats = map[string]Format{
+	"application/json":     JSON,
+	"application/x-ndjson": NDJSON,
+	"application/ndjson":   NDJSON,
+	"application/jsonl":    NDJSON,
+	"text/csv":             CSV,
+	"application/csv":      CSV,
+}
+
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
+
+// FromContentType resolves the format of an uploaded body.
+func FromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if f, ok := formats[strings.ToLower(mediaType)]; ok {
+			return f, nil
+		}
+	}
+	return "", apperror.Newf(apperror.BadRequest, "unsupported content type %s, use application/json, application/x-ndjson or text/csv", contentType)
+}
+
+// Negotiate picks the export format from an explicit name, else the Accept header, defaulting to JSON.
+func Negotiate(name string, accept string) (Format, error) {
+	if len(name) > 0 {
+		f := Format(strings.ToLower(name))
+		if _, ok := contentTypes[f]; !ok {
+			return "", apperror.Newf(apperror.BadRequest, "unsupported format %s, use json, ndjson or csv", name)
+		}
+		return f, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if f, ok := formats[strings.ToLower(mediaType)]; ok {
+			return f, nil
+		}
+	}
+	return JSON, nil
+}
@@ -0,0 +1,224 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+)
+
+type Mode string
+
+const (
+	Atomic     Mode = "atomic"
+	BestEffort Mode = "best_effort"
+)
+
+const (
+	Inserted   = "inserted"
+	Failed     = "failed"
+	RolledBack = "rolled_back"
+	Skipped    = "skipped"
+)
+
+type Config struct {
+	BatchSize int `mapstructure:"batch_size"`
+	MaxRows   int `mapstructure:"max_rows"`
+}
+
+type RowError struct {
+	Code    apperror.Code         `json:"code"`
+	Message string                `json:"message"`
+	Errors  []apperror.FieldError `json:"errors,omitempty"`
+}
+
+type RowResult struct {
+	Row    int       `json:"row"`
+	Id     string    `json:"id,omitempty"`
+	Status string    `json:"status"`
+	Error  *RowError `json:"error,omitempty"`
+}
+
+type Report struct {
+	Mode      Mode        `json:"mode"`
+	Total     int         `json:"total"`
+	Inserted  int         `json:"inserted"`
+	Failed    int         `json:"failed"`
+	Committed bool        `json:"committed"`
+	Rows      []RowResult `json:"rows"`
+}
+
+// Importer inserts decoded rows through insert, batchSize rows per transaction. In Atomic mode all batches share
+// one transaction that is rolled back on the first failure; in BestEffort mode each row runs under a savepoint,
+// failed rows are reported and skipped, and every batch is committed.
+type Importer struct {
+	DB         *sql.DB
+	Repository *repository.Repository
+	Insert     func(ctx context.Context, item interface{}) (int64, error)
+	Validate   func(ctx context.Context, item interface{}) error
+	BatchSize  int
+	MaxRows    int
+}
+
+type pending struct {
+	row  int
+	item interface{}
+}
+
+func (im *Importer) Import(ctx context.Context, reader Reader, mode Mode) (*Report, error) {
+	report := &Report{Mode: mode}
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var tx *sql.Tx
+	var err error
+	if mode == Atomic {
+		if tx, err = im.DB.BeginTx(ctx, nil); err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+	}
+
+	var batch []pending
+	failed := false
+	for row := 1; !failed; row++ {
+		item := reflect.New(im.Repository.Metadata.Type).Interface()
+		err = reader.Next(item)
+		if err == io.EOF {
+			break
+		}
+		if im.MaxRows > 0 && row > im.MaxRows {
+			return nil, apperror.Newf(apperror.BadRequest, "import is limited to %d rows", im.MaxRows)
+		}
+		report.Total++
+		if err == nil && im.Validate != nil {
+			err = im.Validate(ctx, item)
+		}
+		if err != nil {
+			report.fail(row, im.Repository.Key(item), decodeError(err))
+			if _, corrupt := err.(*ErrCorrupt); corrupt {
+				break
+			}
+			failed = mode == Atomic
+			continue
+		}
+		batch = append(batch, pending{row: row, item: item})
+		if len(batch) >= batchSize {
+			failed = !im.flush(ctx, tx, batch, mode, report) && mode == Atomic
+			batch = batch[:0]
+		}
+	}
+	if failed {
+		for _, p := range batch {
+			report.Rows = append(report.Rows, RowResult{Row: p.row, Id: im.Repository.Key(p.item), Status: Skipped})
+		}
+	} else if len(batch) > 0 {
+		failed = !im.flush(ctx, tx, batch, mode, report) && mode == Atomic
+	}
+
+	if mode == Atomic {
+		if failed || report.Failed > 0 {
+			report.rollback()
+			return report, nil
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// flush inserts one batch and reports whether every row succeeded.
+func (im *Importer) flush(ctx context.Context, tx *sql.Tx, batch []pending, mode Mode, report *Report) bool {
+	own := tx == nil
+	if own {
+		var err error
+		if tx, err = im.DB.BeginTx(ctx, nil); err != nil {
+			for _, p := range batch {
+				report.fail(p.row, im.Repository.Key(p.item), err)
+			}
+			return false
+		}
+		defer tx.Rollback()
+	}
+	txCtx := repository.WithTx(ctx, tx)
+	ok := true
+	for i, p := range batch {
+		id := im.Repository.Key(p.item)
+		if mode == BestEffort {
+			if _, err := tx.ExecContext(ctx, im.Repository.Dialect.Savepoint("bulk_row")); err != nil {
+				report.fail(p.row, id, err)
+				ok = false
+				continue
+			}
+		}
+		if _, err := im.Insert(txCtx, p.item); err != nil {
+			report.fail(p.row, id, err)
+			ok = false
+			if mode == Atomic {
+				for _, rest := range batch[i+1:] {
+					report.Rows = append(report.Rows, RowResult{Row: rest.row, Id: im.Repository.Key(rest.item), Status: Skipped})
+				}
+				return false
+			}
+			tx.ExecContext(ctx, im.Repository.Dialect.RollbackTo("bulk_row"))
+			continue
+		}
+		report.Inserted++
+		report.Rows = append(report.Rows, RowResult{Row: p.row, Id: id, Status: Inserted})
+	}
+	if own {
+		if err := tx.Commit(); err != nil {
+			report.Inserted -= countInserted(report.Rows, batch)
+			for _, p := range batch {
+				report.fail(p.row, im.Repository.Key(p.item), fmt.Errorf("commit failed: %v", err))
+			}
+			return false
+		}
+	}
+	return ok
+}
+
+func (r *Report) fail(row int, id string, err error) {
+	e := apperror.As(err)
+	message := e.Message
+	if e.Code == apperror.Internal && e.Err != nil {
+		message = e.Err.Error()
+	}
+	for i := range r.Rows {
+		if r.Rows[i].Row == row {
+			r.Rows = append(r.Rows[:i], r.Rows[i+1:]...)
+			break
+		}
+	}
+	r.Failed++
+	r.Rows = append(r.Rows, RowResult{Row: row, Id: id, Status: Failed, Error: &RowError{Code: e.Code, Message: message, Errors: e.Fields}})
+}
+
+// rollback marks every row inserted in the aborted transaction as rolled back.
+func (r *Report) rollback() {
+	for i := range r.Rows {
+		if r.Rows[i].Status == Inserted {
+			r.Rows[i].Status = RolledBack
+		}
+	}
+	r.Inserted = 0
+}
+
+func countInserted(rows []RowResult, batch []pending) int {
+	n := 0
+	for _, p := range batch {
+		for _, r := range rows {
+			if r.Row == p.row && r.Status == Inserted {
+				n++
+			}
+		}
+	}
+	return n
+}
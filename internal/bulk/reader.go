@@ -0,0 +1,151 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/convert"
+	"go-service/internal/repository"
+)
+
+// Reader decodes one record per call into item, a pointer to the model, and returns io.EOF after the last one.
+type Reader interface {
+	Next(item interface{}) error
+}
+
+// ErrCorrupt marks a decoding failure after which the rest of the stream cannot be read.
+type ErrCorrupt struct {
+	Err error
+}
+
+func (e *ErrCorrupt) Error() string {
+	return e.Err.Error()
+}
+
+func NewReader(format Format, r io.Reader, metadata *repository.Metadata) (Reader, error) {
+	switch format {
+	case JSON:
+		return newJsonReader(r)
+	case NDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &ndjsonReader{scanner: scanner}, nil
+	case CSV:
+		return newCsvReader(r, metadata)
+	}
+	return nil, apperror.Newf(apperror.BadRequest, "unsupported format %s", format)
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+}
+
+func newJsonReader(r io.Reader) (*jsonReader, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, apperror.Wrap(apperror.BadRequest, "body must be a JSON array", err)
+	}
+	if d, ok := token.(json.Delim); !ok || d != '[' {
+		return nil, apperror.New(apperror.BadRequest, "body must be a JSON array")
+	}
+	return &jsonReader{decoder: decoder}, nil
+}
+
+func (r *jsonReader) Next(item interface{}) error {
+	if !r.decoder.More() {
+		return io.EOF
+	}
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return &ErrCorrupt{Err: err}
+	}
+	return json.Unmarshal(raw, item)
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonReader) Next(item interface{}) error {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return json.Unmarshal(line, item)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return &ErrCorrupt{Err: err}
+	}
+	return io.EOF
+}
+
+// csvReader maps columns to model fields by the json names in the header row.
+type csvReader struct {
+	reader *csv.Reader
+	fields []int
+	header []string
+}
+
+func newCsvReader(r io.Reader, metadata *repository.Metadata) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, apperror.Wrap(apperror.BadRequest, "missing CSV header", err)
+	}
+	fields := make([]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		f, ok := metadata.FieldByJson(name)
+		if !ok {
+			return nil, apperror.Newf(apperror.BadRequest, "unknown CSV column %s", name)
+		}
+		fields[i] = f.Index
+		header[i] = name
+	}
+	reader.FieldsPerRecord = len(header)
+	return &csvReader{reader: reader, fields: fields, header: header}, nil
+}
+
+func (r *csvReader) Next(item interface{}) error {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if e, ok := err.(*csv.ParseError); ok && e.Err == csv.ErrFieldCount {
+		return apperror.Newf(apperror.BadRequest, "expected %d columns, got %d", len(r.header), len(record))
+	}
+	if err != nil {
+		return &ErrCorrupt{Err: err}
+	}
+	v := reflect.ValueOf(item).Elem()
+	var errs []apperror.FieldError
+	for i, value := range record {
+		if len(value) == 0 {
+			continue
+		}
+		if err := convert.Set(v.Field(r.fields[i]), []string{value}); err != nil {
+			errs = append(errs, apperror.FieldError{Field: r.header[i], Code: "type", Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return apperror.NewValidation(errs)
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	if _, ok := err.(*apperror.Error); ok {
+		return err
+	}
+	return apperror.New(apperror.BadRequest, fmt.Sprintf("cannot decode row: %v", err))
+}
@@ -0,0 +1,115 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"go-service/internal/convert"
+	"go-service/internal/repository"
+)
+
+// Writer encodes models one at a time; Close completes the document.
+type Writer interface {
+	Write(item interface{}) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer, metadata *repository.Metadata) Writer {
+	switch format {
+	case NDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}
+	case CSV:
+		return &csvWriter{writer: csv.NewWriter(w), metadata: metadata}
+	default:
+		return &jsonWriter{w: w}
+	}
+}
+
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(item interface{}) error {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if j.count == 0 {
+		prefix = "[\n"
+	}
+	j.count++
+	if _, err = io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(item interface{}) error {
+	return n.encoder.Encode(item)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes a header of json names followed by one record per model.
+type csvWriter struct {
+	writer   *csv.Writer
+	metadata *repository.Metadata
+	started  bool
+}
+
+func (c *csvWriter) Write(item interface{}) error {
+	if !c.started {
+		if err := c.header(); err != nil {
+			return err
+		}
+	}
+	v := reflect.Indirect(reflect.ValueOf(item))
+	record := make([]string, len(c.metadata.Fields))
+	for i, f := range c.metadata.Fields {
+		record[i] = convert.Format(v.Field(f.Index))
+	}
+	if err := c.writer.Write(record); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if !c.started {
+		if err := c.header(); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter) header() error {
+	c.started = true
+	header := make([]string, len(c.metadata.Fields))
+	for i, f := range c.metadata.Fields {
+		header[i] = f.Json
+	}
+	return c.writer.Write(header)
+}
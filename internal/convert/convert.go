@@ -0,0 +1,112 @@
+package convert
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-service/internal/apperror"
+)
+
+var TimeType = reflect.TypeOf(time.Time{})
+
+// Set converts raw text values into v, allocating pointers as needed. Slices of strings take every value,
+// split on commas; other kinds take the last value.
+func Set(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		var items []string
+		for _, r := range raw {
+			for _, item := range strings.Split(r, ",") {
+				if item = strings.TrimSpace(item); len(item) > 0 {
+					items = append(items, item)
+				}
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	}
+	s := raw[len(raw)-1]
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := Set(p.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.Type() == TimeType {
+		t, err := ParseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return apperror.Newf(apperror.BadRequest, "%s is not a boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return apperror.Newf(apperror.BadRequest, "%s is not an integer", s)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return apperror.Newf(apperror.BadRequest, "%s is not a number", s)
+		}
+		v.SetFloat(n)
+	default:
+		return apperror.Newf(apperror.BadRequest, "unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return t, apperror.Newf(apperror.BadRequest, "%s is not a date (use 2006-01-02 or RFC 3339)", s)
+	}
+	return t, nil
+}
+
+// Format renders v as text, the inverse of Set; nil pointers become "".
+func Format(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Type() == TimeType {
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = Format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return ""
+}
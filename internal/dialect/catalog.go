@@ -0,0 +1,26 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+)
+
+// HasTable reports whether table exists in the current schema, reading the catalog only.
+func (d *Dialect) HasTable(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	var query string
+	switch d.Name {
+	case Postgres:
+		query = "select count(*) from information_schema.tables where table_schema = current_schema() and table_name = $1"
+	case SQLite:
+		query = "select count(*) from sqlite_master where type = 'table' and name = ?"
+	case SQLServer:
+		query = "select count(*) from information_schema.tables where table_schema = schema_name() and table_name = @p1"
+	default:
+		query = "select count(*) from information_schema.tables where table_schema = database() and table_name = ?"
+	}
+	var count int
+	if err := db.QueryRowContext(ctx, query, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
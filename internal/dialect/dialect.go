@@ -0,0 +1,224 @@
+package dialect
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	MySQL     = "mysql"
+	Postgres  = "postgres"
+	SQLite    = "sqlite"
+	SQLServer = "sqlserver"
+)
+
+// Dialect writes the parts of SQL statements that differ between the supported databases.
+type Dialect struct {
+	Name string
+}
+
+var names = map[string]string{
+	"mysql":     MySQL,
+	"postgres":  Postgres,
+	"pgx":       Postgres,
+	"sqlite":    SQLite,
+	"sqlite3":   SQLite,
+	"sqlserver": SQLServer,
+	"mssql":     SQLServer,
+}
+
+var drivers = map[string]string{
+	"*mysql.MySQLDriver":    MySQL,
+	"*pq.Driver":            Postgres,
+	"*stdlib.Driver":        Postgres,
+	"*sqlite.Driver":        SQLite,
+	"*sqlite3.SQLiteDriver": SQLite,
+	"*mssql.Driver":         SQLServer,
+}
+
+// ByName returns the dialect of a database/sql driver name, such as "mysql" or "sqlserver".
+func ByName(name string) (*Dialect, error) {
+	if d, ok := names[strings.ToLower(name)]; ok {
+		return &Dialect{Name: d}, nil
+	}
+	return nil, fmt.Errorf("unsupported sql driver %s, use mysql, postgres, sqlite or sqlserver", name)
+}
+
+// Of returns the dialect of the driver db was opened with, looking through wrapping drivers
+// that expose the wrapped one with Unwrap. Unknown drivers are taken as MySQL.
+func Of(db *sql.DB) *Dialect {
+	var d driver.Driver = db.Driver()
+	for {
+		w, ok := d.(interface{ Unwrap() driver.Driver })
+		if !ok {
+			break
+		}
+		d = w.Unwrap()
+	}
+	if name, ok := drivers[reflect.TypeOf(d).String()]; ok {
+		return &Dialect{Name: name}
+	}
+	return &Dialect{Name: MySQL}
+}
+
+// Param returns the placeholder of the i-th statement parameter, counting from 1.
+func (d *Dialect) Param(i int) string {
+	switch d.Name {
+	case Postgres:
+		return fmt.Sprintf("$%d", i)
+	case SQLServer:
+		return fmt.Sprintf("@p%d", i)
+	}
+	return "?"
+}
+
+// Page returns the clause that follows "order by" to return at most limit rows after skipping offset, with
+// placeholders numbered from start, and its parameters. Passing limit and offset as parameters keeps one prepared
+// statement for every page. SQL Server cannot page without an order, so unordered queries get a neutral one.
+func (d *Dialect) Page(limit, offset int64, ordered bool, start int) (string, []interface{}) {
+	if d.Name == SQLServer {
+		page := fmt.Sprintf(" offset %s rows fetch next %s rows only", d.Param(start), d.Param(start+1))
+		if !ordered {
+			page = " order by (select null)" + page
+		}
+		return page, []interface{}{offset, limit}
+	}
+	return fmt.Sprintf(" limit %s offset %s", d.Param(start), d.Param(start+1)), []interface{}{limit, offset}
+}
+
+// Order returns the direction of one "order by" term. NULLs always sort first when ascending and last
+// when descending, as MySQL, SQLite and SQL Server do, which keyset pagination relies on.
+func (d *Dialect) Order(column string, desc bool) string {
+	if desc {
+		if d.Name == Postgres {
+			return column + " desc nulls last"
+		}
+		return column + " desc"
+	}
+	if d.Name == Postgres {
+		return column + " nulls first"
+	}
+	return column
+}
+
+// From returns the table of a "select ... for update", with the lock hint SQL Server takes instead of the clause.
+func (d *Dialect) From(table string, forUpdate bool) string {
+	if forUpdate && d.Name == SQLServer {
+		return table + " with (updlock, rowlock)"
+	}
+	return table
+}
+
+// ForUpdate returns the clause locking the selected rows until the transaction ends. SQLite locks the whole
+// database on the first write of a transaction instead, and SQL Server uses the hint added by From.
+func (d *Dialect) ForUpdate() string {
+	if d.Name == MySQL || d.Name == Postgres {
+		return " for update"
+	}
+	return ""
+}
+
+// DeleteLimit returns a statement deleting at most limit rows of table matching where, whose keys are keys.
+func (d *Dialect) DeleteLimit(table string, keys []string, where string, limit int) string {
+	switch d.Name {
+	case MySQL:
+		return fmt.Sprintf("delete from %s where %s limit %d", table, where, limit)
+	case SQLServer:
+		return fmt.Sprintf("delete top (%d) from %s where %s", limit, table, where)
+	}
+	key := strings.Join(keys, ", ")
+	if len(keys) > 1 {
+		key = "(" + key + ")"
+	}
+	return fmt.Sprintf("delete from %s where %s in (select %s from %s where %s limit %d)", table, key, strings.Join(keys, ", "), table, where, limit)
+}
+
+// Upsert returns a statement inserting a row of keys and columns, in that parameter order,
+// or updating columns when a row with the same keys exists.
+func (d *Dialect) Upsert(table string, keys []string, columns []string) string {
+	all := append(append([]string{}, keys...), columns...)
+	params := make([]string, len(all))
+	for i := range all {
+		params[i] = d.Param(i + 1)
+	}
+	sets := make([]string, len(columns))
+	switch d.Name {
+	case MySQL:
+		for i, c := range columns {
+			sets[i] = fmt.Sprintf("%s = values(%s)", c, c)
+		}
+		return fmt.Sprintf("insert into %s (%s) values (%s) on duplicate key update %s",
+			table, strings.Join(all, ", "), strings.Join(params, ", "), strings.Join(sets, ", "))
+	case SQLServer:
+		on := make([]string, len(keys))
+		for i, k := range keys {
+			on[i] = fmt.Sprintf("t.%s = s.%s", k, k)
+		}
+		for i, c := range columns {
+			sets[i] = fmt.Sprintf("%s = s.%s", c, c)
+		}
+		values := make([]string, len(all))
+		for i, c := range all {
+			values[i] = "s." + c
+		}
+		return fmt.Sprintf("merge into %s with (holdlock) as t using (select %s) as s (%s) on %s when matched then update set %s when not matched then insert (%s) values (%s);",
+			table, strings.Join(params, ", "), strings.Join(all, ", "), strings.Join(on, " and "), strings.Join(sets, ", "),
+			strings.Join(all, ", "), strings.Join(values, ", "))
+	}
+	for i, c := range columns {
+		sets[i] = fmt.Sprintf("%s = excluded.%s", c, c)
+	}
+	return fmt.Sprintf("insert into %s (%s) values (%s) on conflict (%s) do update set %s",
+		table, strings.Join(all, ", "), strings.Join(params, ", "), strings.Join(keys, ", "), strings.Join(sets, ", "))
+}
+
+func (d *Dialect) Savepoint(name string) string {
+	if d.Name == SQLServer {
+		return "save transaction " + name
+	}
+	return "savepoint " + name
+}
+
+func (d *Dialect) RollbackTo(name string) string {
+	if d.Name == SQLServer {
+		return "rollback transaction " + name
+	}
+	return "rollback to savepoint " + name
+}
+
+// CreateTable returns a statement creating table with the given column definitions unless it exists.
+func (d *Dialect) CreateTable(table string, definition string) string {
+	if d.Name == SQLServer {
+		return fmt.Sprintf("if object_id(N'%s', N'U') is null create table %s (%s)", table, table, definition)
+	}
+	return fmt.Sprintf("create table if not exists %s (%s)", table, definition)
+}
+
+// Timestamp is the column type of a date and time without time zone.
+func (d *Dialect) Timestamp() string {
+	switch d.Name {
+	case Postgres:
+		return "timestamp"
+	case SQLServer:
+		return "datetime2"
+	}
+	return "datetime"
+}
+
+// Limit adds a server side execution limit to a select. Only MySQL takes one per statement, as an optimizer hint;
+// the other drivers cancel the running statement when its context expires.
+func (d *Dialect) Limit(query string, timeout time.Duration) string {
+	trimmed := strings.TrimLeft(query, " \t\r\n")
+	if d.Name != MySQL || len(trimmed) < 7 || !strings.EqualFold(trimmed[:7], "select ") {
+		return query
+	}
+	ms := int64(timeout / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("select /*+ MAX_EXECUTION_TIME(%d) */ %s", ms, trimmed[7:])
+}
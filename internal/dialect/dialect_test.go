@@ -0,0 +1,163 @@
+package dialect
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var all = []string{MySQL, Postgres, SQLite, SQLServer}
+
+// each runs check with the dialect of every database against the value want holds for it.
+func each(t *testing.T, want []string, check func(d *Dialect) string) {
+	t.Helper()
+	for i, name := range all {
+		if got := check(&Dialect{Name: name}); got != want[i] {
+			t.Errorf("%s: got %q, want %q", name, got, want[i])
+		}
+	}
+}
+
+func TestByName(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"mysql", MySQL},
+		{"postgres", Postgres},
+		{"pgx", Postgres},
+		{"sqlite", SQLite},
+		{"sqlite3", SQLite},
+		{"sqlserver", SQLServer},
+		{"MSSQL", SQLServer},
+		{"oracle", ""},
+	}
+	for _, tt := range tests {
+		d, err := ByName(tt.driver)
+		if len(tt.want) == 0 {
+			if err == nil {
+				t.Errorf("%s: got dialect %s", tt.driver, d.Name)
+			}
+			continue
+		}
+		if err != nil || d.Name != tt.want {
+			t.Errorf("%s: got %v, %v", tt.driver, d, err)
+		}
+	}
+}
+
+func TestParam(t *testing.T) {
+	each(t, []string{"?", "$3", "?", "@p3"}, func(d *Dialect) string { return d.Param(3) })
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		want    []string
+		params  [][]interface{}
+	}{
+		{
+			name:    "ordered",
+			ordered: true,
+			want:    []string{" limit ? offset ?", " limit $4 offset $5", " limit ? offset ?", " offset @p4 rows fetch next @p5 rows only"},
+		},
+		{
+			name: "unordered",
+			want: []string{" limit ? offset ?", " limit $4 offset $5", " limit ? offset ?", " order by (select null) offset @p4 rows fetch next @p5 rows only"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, name := range all {
+				page, params := (&Dialect{Name: name}).Page(20, 40, tt.ordered, 4)
+				if page != tt.want[i] {
+					t.Errorf("%s: got %q, want %q", name, page, tt.want[i])
+				}
+				want := []interface{}{int64(20), int64(40)}
+				if name == SQLServer {
+					want = []interface{}{int64(40), int64(20)}
+				}
+				if !reflect.DeepEqual(params, want) {
+					t.Errorf("%s: got params %v, want %v", name, params, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOrder(t *testing.T) {
+	each(t, []string{"name", "name nulls first", "name", "name"}, func(d *Dialect) string { return d.Order("name", false) })
+	each(t, []string{"name desc", "name desc nulls last", "name desc", "name desc"}, func(d *Dialect) string { return d.Order("name", true) })
+}
+
+func TestLocking(t *testing.T) {
+	each(t, []string{"users", "users", "users", "users with (updlock, rowlock)"}, func(d *Dialect) string { return d.From("users", true) })
+	each(t, []string{"users", "users", "users", "users"}, func(d *Dialect) string { return d.From("users", false) })
+	each(t, []string{" for update", " for update", "", ""}, func(d *Dialect) string { return d.ForUpdate() })
+}
+
+func TestDeleteLimit(t *testing.T) {
+	each(t, []string{
+		"delete from audit where at < ? limit 100",
+		"delete from audit where id in (select id from audit where at < ? limit 100)",
+		"delete from audit where id in (select id from audit where at < ? limit 100)",
+		"delete top (100) from audit where at < ?",
+	}, func(d *Dialect) string { return d.DeleteLimit("audit", []string{"id"}, "at < ?", 100) })
+	each(t, []string{
+		"delete from roles where at < ? limit 5",
+		"delete from roles where (id, role) in (select id, role from roles where at < ? limit 5)",
+		"delete from roles where (id, role) in (select id, role from roles where at < ? limit 5)",
+		"delete top (5) from roles where at < ?",
+	}, func(d *Dialect) string { return d.DeleteLimit("roles", []string{"id", "role"}, "at < ?", 5) })
+}
+
+func TestUpsert(t *testing.T) {
+	each(t, []string{
+		"insert into locks (name, owner, until) values (?, ?, ?) on duplicate key update owner = values(owner), until = values(until)",
+		"insert into locks (name, owner, until) values ($1, $2, $3) on conflict (name) do update set owner = excluded.owner, until = excluded.until",
+		"insert into locks (name, owner, until) values (?, ?, ?) on conflict (name) do update set owner = excluded.owner, until = excluded.until",
+		"merge into locks with (holdlock) as t using (select @p1, @p2, @p3) as s (name, owner, until) on t.name = s.name " +
+			"when matched then update set owner = s.owner, until = s.until when not matched then insert (name, owner, until) values (s.name, s.owner, s.until);",
+	}, func(d *Dialect) string { return d.Upsert("locks", []string{"name"}, []string{"owner", "until"}) })
+}
+
+func TestSavepoint(t *testing.T) {
+	each(t, []string{"savepoint sp1", "savepoint sp1", "savepoint sp1", "save transaction sp1"}, func(d *Dialect) string { return d.Savepoint("sp1") })
+	each(t, []string{"rollback to savepoint sp1", "rollback to savepoint sp1", "rollback to savepoint sp1", "rollback transaction sp1"}, func(d *Dialect) string { return d.RollbackTo("sp1") })
+}
+
+func TestCreateTable(t *testing.T) {
+	each(t, []string{
+		"create table if not exists locks (name varchar(100))",
+		"create table if not exists locks (name varchar(100))",
+		"create table if not exists locks (name varchar(100))",
+		"if object_id(N'locks', N'U') is null create table locks (name varchar(100))",
+	}, func(d *Dialect) string { return d.CreateTable("locks", "name varchar(100)") })
+	each(t, []string{"datetime", "timestamp", "datetime", "datetime2"}, func(d *Dialect) string { return d.Timestamp() })
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		query   string
+		timeout time.Duration
+		want    string
+	}{
+		{"select id from users", 2 * time.Second, "select /*+ MAX_EXECUTION_TIME(2000) */ id from users"},
+		{"\n  SELECT id from users", 2 * time.Second, "select /*+ MAX_EXECUTION_TIME(2000) */ id from users"},
+		{"select id from users", time.Microsecond, "select /*+ MAX_EXECUTION_TIME(1) */ id from users"},
+		{"update users set name = ?", time.Second, "update users set name = ?"},
+		{"selected", time.Second, "selected"},
+		{"select", time.Second, "select"},
+	}
+	for _, tt := range tests {
+		if got := (&Dialect{Name: MySQL}).Limit(tt.query, tt.timeout); got != tt.want {
+			t.Errorf("Limit(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+		for _, name := range all[1:] {
+			if got := (&Dialect{Name: name}).Limit(tt.query, tt.timeout); got != tt.query {
+				t.Errorf("%s: Limit(%q) = %q", name, tt.query, got)
+			}
+		}
+	}
+}
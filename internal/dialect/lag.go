@@ -0,0 +1,74 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Lag returns how far the replica db is behind its primary. A database that is not replicating reports 0,
+// and so does SQLite, which has no replicas. On PostgreSQL the lag is the age of the last replayed transaction,
+// so it also grows while the primary is idle.
+func (d *Dialect) Lag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	switch d.Name {
+	case MySQL:
+		return mysqlLag(ctx, db)
+	case Postgres:
+		var seconds float64
+		query := "select case when pg_is_in_recovery() then coalesce(extract(epoch from now() - pg_last_xact_replay_timestamp()), 0) else 0 end"
+		if err := db.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	case SQLServer:
+		var seconds int64
+		query := "select coalesce(max(secondary_lag_seconds), 0) from sys.dm_hadr_database_replica_states where is_local = 1"
+		if err := db.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, nil
+}
+
+// mysqlLag reads Seconds_Behind_Source, named Seconds_Behind_Master before MySQL 8.0.22, from the replica status.
+func mysqlLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "show replica status")
+	if err != nil {
+		if rows, err = db.QueryContext(ctx, "show slave status"); err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err = rows.Scan(targets...); err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,61 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Lock takes the advisory lock name on conn, waiting at most timeout seconds, and returns the function releasing it.
+// SQLite has no advisory locks; a database file is only migrated by the processes sharing it, which it serializes.
+func (d *Dialect) Lock(ctx context.Context, conn *sql.Conn, name string, timeout int64) (func(), error) {
+	failed := fmt.Errorf("cannot acquire lock %s within %d seconds", name, timeout)
+	switch d.Name {
+	case MySQL:
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "select get_lock(?, ?)", name, timeout).Scan(&locked); err != nil {
+			return nil, err
+		}
+		if !locked.Valid || locked.Int64 != 1 {
+			return nil, failed
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "select release_lock(?)", name)
+		}, nil
+	case Postgres:
+		deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+		for {
+			var locked bool
+			if err := conn.QueryRowContext(ctx, "select pg_try_advisory_lock(hashtext($1))", name).Scan(&locked); err != nil {
+				return nil, err
+			}
+			if locked {
+				return func() {
+					conn.ExecContext(context.Background(), "select pg_advisory_unlock(hashtext($1))", name)
+				}, nil
+			}
+			if time.Now().After(deadline) {
+				return nil, failed
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	case SQLServer:
+		var result int
+		query := "declare @r int; exec @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2; select @r"
+		if err := conn.QueryRowContext(ctx, query, name, timeout*1000).Scan(&result); err != nil {
+			return nil, err
+		}
+		if result < 0 {
+			return nil, failed
+		}
+		return func() {
+			conn.ExecContext(context.Background(), "exec sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", name)
+		}, nil
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,18 @@
+package filter
+
+import . "go-service/internal/model"
+
+type AuditFilter struct {
+	Entity    string `json:"entity,omitempty" validate:"max=40"`
+	Id        string `json:"id,omitempty" validate:"max=255"`
+	Operation string `json:"operation,omitempty" validate:"omitempty,oneof=insert update patch delete restore password"`
+	Actor     string `json:"actor,omitempty" validate:"max=120"`
+	Cursor    string `json:"cursor,omitempty" validate:"max=2000"`
+	Limit     int64  `json:"limit,omitempty" validate:"min=0,max=1000"`
+}
+
+type AuditResult struct {
+	List       []AuditLog `json:"list,omitempty"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+	PrevCursor string     `json:"prevCursor,omitempty"`
+}
@@ -3,12 +3,21 @@ package filter
 import . "go-service/internal/model"
 
 type MovieFilter struct {
-	Id      string `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" validate:"required,max=40"`
-	Name    string `json:"name" gorm:"column:name" bson:"name" dynamodbav:"name" firestore:"name" validate:"required,name,max=100"`
-	Watched bool   `json:"watched" validate:"required"`
+	Id        string   `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" match:"equal" validate:"omitempty,max=40"`
+	Name      string   `json:"name" gorm:"column:name" bson:"name" dynamodbav:"name" firestore:"name" match:"contain" validate:"omitempty,max=100"`
+	Watched   *bool    `json:"watched,omitempty" gorm:"column:watched" bson:"watched" dynamodbav:"watched" firestore:"watched"`
+	PageIndex int64    `json:"pageIndex,omitempty" validate:"min=0"`
+	PageSize  int64    `json:"pageSize,omitempty" validate:"min=0,max=1000"`
+	Sort      string   `json:"sort,omitempty" validate:"max=100"`
+	Cursor    string   `json:"cursor,omitempty" validate:"max=2000"`
+	Limit     int64    `json:"limit,omitempty" validate:"min=0,max=1000"`
+	Fields    []string `json:"fields,omitempty" validate:"max=20"`
+	SkipTotal bool     `json:"skipTotal,omitempty"`
 }
 
 type ResultMovie struct {
-	List  []Movie `mapstructure:"list" json:"list,omitempty" gorm:"column:list" bson:"list,omitempty" dynamodbav:"list,omitempty" firestore:"list,omitempty"`
-	Total int64   `mapstructure:"total" json:"total,omitempty" gorm:"column:total" bson:"total,omitempty" dynamodbav:"total,omitempty" firestore:"total,omitempty"`
+	List       []Movie `mapstructure:"list" json:"list,omitempty" gorm:"column:list" bson:"list,omitempty" dynamodbav:"list,omitempty" firestore:"list,omitempty"`
+	Total      int64   `mapstructure:"total" json:"total,omitempty" gorm:"column:total" bson:"total,omitempty" dynamodbav:"total,omitempty" firestore:"total,omitempty"`
+	NextCursor string  `mapstructure:"nextCursor" json:"nextCursor,omitempty" gorm:"column:nextCursor" bson:"nextCursor,omitempty" dynamodbav:"nextCursor,omitempty" firestore:"nextCursor,omitempty"`
+	PrevCursor string  `mapstructure:"prevCursor" json:"prevCursor,omitempty" gorm:"column:prevCursor" bson:"prevCursor,omitempty" dynamodbav:"prevCursor,omitempty" firestore:"prevCursor,omitempty"`
 }
@@ -0,0 +1,13 @@
+package filter
+
+import "time"
+
+type TimeRange struct {
+	Min *time.Time `mapstructure:"min" json:"min,omitempty" bson:"min,omitempty" dynamodbav:"min,omitempty" firestore:"min,omitempty"`
+	Max *time.Time `mapstructure:"max" json:"max,omitempty" bson:"max,omitempty" dynamodbav:"max,omitempty" firestore:"max,omitempty"`
+}
+
+type NumberRange struct {
+	Min *float64 `mapstructure:"min" json:"min,omitempty" bson:"min,omitempty" dynamodbav:"min,omitempty" firestore:"min,omitempty"`
+	Max *float64 `mapstructure:"max" json:"max,omitempty" bson:"max,omitempty" dynamodbav:"max,omitempty" firestore:"max,omitempty"`
+}
@@ -3,15 +3,23 @@ package filter
 import . "go-service/internal/model"
 
 type UserFilter struct {
-	Id        string `mapstructure:"id" json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" match:"equal" validate:"required,max=40"`
-	Username  string `mapstructure:"username" json:"username" gorm:"column:username" bson:"username" dynamodbav:"username" firestore:"username" match:"prefix" validate:"required,username,max=100"`
-	Email     string `mapstructure:"email" json:"email" gorm:"column:email" bson:"email" dynamodbav:"email" firestore:"email" match:"prefix" validate:"email,max=100"`
-	Phone     string `mapstructure:"phone" json:"phone" gorm:"column:phone" bson:"phone" dynamodbav:"phone" firestore:"phone" validate:"required,phone,max=18"`
-	PageIndex int64  `mapstructure:"pageIndex" json:"pageIndex,omitempty" gorm:"column:pageIndex" bson:"pageIndex,omitempty" dynamodbav:"pageIndex,omitempty" firestore:"pageIndex,omitempty"`
-	PageSize  int64  `mapstructure:"pageSize" json:"pageSize,omitempty" gorm:"column:pageSize" bson:"pageSize,omitempty" dynamodbav:"pageSize,omitempty" firestore:"pageSize,omitempty"`
+	Id          string     `mapstructure:"id" json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" match:"equal" validate:"omitempty,max=40"`
+	Username    string     `mapstructure:"username" json:"username" gorm:"column:username" bson:"username" dynamodbav:"username" firestore:"username" match:"prefix" validate:"omitempty,max=100"`
+	Email       string     `mapstructure:"email" json:"email" gorm:"column:email" bson:"email" dynamodbav:"email" firestore:"email" match:"prefix" validate:"omitempty,max=100"`
+	Phone       string     `mapstructure:"phone" json:"phone" gorm:"column:phone" bson:"phone" dynamodbav:"phone" firestore:"phone" match:"contain" validate:"omitempty,max=18"`
+	DateOfBirth *TimeRange `mapstructure:"dateOfBirth" json:"dateOfBirth,omitempty" gorm:"column:date_of_birth" bson:"dateOfBirth,omitempty" dynamodbav:"dateOfBirth,omitempty" firestore:"dateOfBirth,omitempty"`
+	PageIndex   int64      `mapstructure:"pageIndex" json:"pageIndex,omitempty" gorm:"column:pageIndex" bson:"pageIndex,omitempty" dynamodbav:"pageIndex,omitempty" firestore:"pageIndex,omitempty" validate:"min=0"`
+	PageSize    int64      `mapstructure:"pageSize" json:"pageSize,omitempty" gorm:"column:pageSize" bson:"pageSize,omitempty" dynamodbav:"pageSize,omitempty" firestore:"pageSize,omitempty" validate:"min=0,max=1000"`
+	Sort        string     `mapstructure:"sort" json:"sort,omitempty" gorm:"column:sort" bson:"sort,omitempty" dynamodbav:"sort,omitempty" firestore:"sort,omitempty" validate:"max=100"`
+	Cursor      string     `mapstructure:"cursor" json:"cursor,omitempty" gorm:"column:cursor" bson:"cursor,omitempty" dynamodbav:"cursor,omitempty" firestore:"cursor,omitempty" validate:"max=2000"`
+	Limit       int64      `mapstructure:"limit" json:"limit,omitempty" gorm:"column:limit" bson:"limit,omitempty" dynamodbav:"limit,omitempty" firestore:"limit,omitempty" validate:"min=0,max=1000"`
+	Fields      []string   `mapstructure:"fields" json:"fields,omitempty" gorm:"column:fields" bson:"fields,omitempty" dynamodbav:"fields,omitempty" firestore:"fields,omitempty" validate:"max=20"`
+	SkipTotal   bool       `mapstructure:"skipTotal" json:"skipTotal,omitempty" gorm:"column:skipTotal" bson:"skipTotal,omitempty" dynamodbav:"skipTotal,omitempty" firestore:"skipTotal,omitempty"`
 }
 
 type Result struct {
-	List  []User `mapstructure:"list" json:"list,omitempty" gorm:"column:list" bson:"list,omitempty" dynamodbav:"list,omitempty" firestore:"list,omitempty"`
-	Total int64  `mapstructure:"total" json:"total,omitempty" gorm:"column:total" bson:"total,omitempty" dynamodbav:"total,omitempty" firestore:"total,omitempty"`
+	List       []User `mapstructure:"list" json:"list,omitempty" gorm:"column:list" bson:"list,omitempty" dynamodbav:"list,omitempty" firestore:"list,omitempty"`
+	Total      int64  `mapstructure:"total" json:"total,omitempty" gorm:"column:total" bson:"total,omitempty" dynamodbav:"total,omitempty" firestore:"total,omitempty"`
+	NextCursor string `mapstructure:"nextCursor" json:"nextCursor,omitempty" gorm:"column:nextCursor" bson:"nextCursor,omitempty" dynamodbav:"nextCursor,omitempty" firestore:"nextCursor,omitempty"`
+	PrevCursor string `mapstructure:"prevCursor" json:"prevCursor,omitempty" gorm:"column:prevCursor" bson:"prevCursor,omitempty" dynamodbav:"prevCursor,omitempty" firestore:"prevCursor,omitempty"`
 }
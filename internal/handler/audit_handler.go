@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	. "go-service/internal/filter"
+	. "go-service/internal/service"
+	"go-service/internal/validation"
+)
+
+type AuditHandler struct {
+	service   AuditService
+	validator *validation.Validator
+}
+
+func NewAuditHandler(service AuditService, validator *validation.Validator) *AuditHandler {
+	return &AuditHandler{service: service, validator: validator}
+}
+
+func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var filter AuditFilter
+	if err := DecodeQuery(r.URL.Query(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	res, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	JSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	. "go-service/internal/model"
+	. "go-service/internal/service"
+	"go-service/internal/validation"
+)
+
+type AuthHandler struct {
+	service   AuthService
+	validator *validation.Validator
+}
+
+func NewAuthHandler(service AuthService, validator *validation.Validator) *AuthHandler {
+	return &AuthHandler{service: service, validator: validator}
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var login Login
+	er1 := json.NewDecoder(r.Body).Decode(&login)
+	defer r.Body.Close()
+	if er1 != nil {
+		BadRequest(w, r, er1.Error())
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &login); err != nil {
+		Error(w, r, err)
+		return
+	}
+	token, er2 := h.service.Login(r.Context(), login)
+	if er2 != nil {
+		Error(w, r, er2)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	JSON(w, http.StatusOK, token)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var refresh Refresh
+	er1 := json.NewDecoder(r.Body).Decode(&refresh)
+	defer r.Body.Close()
+	if er1 != nil {
+		BadRequest(w, r, er1.Error())
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &refresh); err != nil {
+		Error(w, r, err)
+		return
+	}
+	token, er2 := h.service.Refresh(r.Context(), refresh.RefreshToken)
+	if er2 != nil {
+		Error(w, r, er2)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	JSON(w, http.StatusOK, token)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var refresh Refresh
+	er1 := json.NewDecoder(r.Body).Decode(&refresh)
+	defer r.Body.Close()
+	if er1 != nil {
+		BadRequest(w, r, er1.Error())
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &refresh); err != nil {
+		Error(w, r, err)
+		return
+	}
+	if er2 := h.service.Logout(r.Context(), refresh.RefreshToken); er2 != nil {
+		Error(w, r, er2)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		BadRequest(w, r, "Id cannot be empty")
+		return
+	}
+	var change PasswordChange
+	er1 := json.NewDecoder(r.Body).Decode(&change)
+	defer r.Body.Close()
+	if er1 != nil {
+		BadRequest(w, r, er1.Error())
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &change); err != nil {
+		Error(w, r, err)
+		return
+	}
+	if er2 := h.service.ChangePassword(r.Context(), id, change); er2 != nil {
+		Error(w, r, er2)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
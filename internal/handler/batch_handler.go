@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+	"go-service/internal/retry"
+	"go-service/internal/validation"
+)
+
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpPatch  = "patch"
+	OpDelete = "delete"
+)
+
+var isolationLevels = map[string]sql.IsolationLevel{
+	"":                 sql.LevelDefault,
+	"default":          sql.LevelDefault,
+	"read_uncommitted": sql.LevelReadUncommitted,
+	"read_committed":   sql.LevelReadCommitted,
+	"repeatable_read":  sql.LevelRepeatableRead,
+	"serializable":     sql.LevelSerializable,
+}
+
+type BatchConfig struct {
+	Isolation     string `mapstructure:"isolation"`
+	MaxOperations int    `mapstructure:"max_operations"`
+}
+
+// BatchEntity exposes the write operations of one resource to POST /batch.
+type BatchEntity struct {
+	Repository *repository.Repository
+	Insert     func(ctx context.Context, item interface{}) (int64, error)
+	Update     func(ctx context.Context, item interface{}) (int64, error)
+	Patch      func(ctx context.Context, item map[string]interface{}) (int64, error)
+	Delete     func(ctx context.Context, id string) (int64, error)
+}
+
+type Operation struct {
+	Op      string          `json:"op"`
+	Entity  string          `json:"entity"`
+	Id      string          `json:"id,omitempty"`
+	IfMatch string          `json:"ifMatch,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+type BatchRequest struct {
+	Isolation  string      `json:"isolation,omitempty"`
+	Operations []Operation `json:"operations"`
+}
+
+type OperationResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Entity string `json:"entity"`
+	Id     string `json:"id,omitempty"`
+	Result int64  `json:"result"`
+}
+
+type BatchResult struct {
+	Results []OperationResult `json:"results"`
+}
+
+type BatchHandler struct {
+	db        *sql.DB
+	entities  map[string]BatchEntity
+	validator *validation.Validator
+	config    BatchConfig
+	retrier   *retry.Policy
+}
+
+func NewBatchHandler(db *sql.DB, entities map[string]BatchEntity, validator *validation.Validator, config BatchConfig, retrier *retry.Policy) *BatchHandler {
+	return &BatchHandler{db: db, entities: entities, validator: validator, config: config, retrier: retrier}
+}
+
+// Batch applies the operations in order inside one transaction. The first failing operation rolls back
+// every change and is reported in the problem's errors as "operations[<index>]".
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	defer r.Body.Close()
+	if err != nil {
+		BadRequest(w, r, err.Error())
+		return
+	}
+	if len(req.Operations) == 0 {
+		BadRequest(w, r, "operations cannot be empty")
+		return
+	}
+	if h.config.MaxOperations > 0 && len(req.Operations) > h.config.MaxOperations {
+		BadRequest(w, r, fmt.Sprintf("a batch is limited to %d operations", h.config.MaxOperations))
+		return
+	}
+	isolation := req.Isolation
+	if len(isolation) == 0 {
+		isolation = h.config.Isolation
+	}
+	level, ok := isolationLevels[strings.ToLower(isolation)]
+	if !ok {
+		BadRequest(w, r, fmt.Sprintf("unsupported isolation level %s", isolation))
+		return
+	}
+
+	var results []OperationResult
+	// A deadlock rolls back the whole transaction, so the whole batch is what runs again.
+	err = h.retrier.Do(r.Context(), "batch", false, func(ctx context.Context) error {
+		results = make([]OperationResult, 0, len(req.Operations))
+		return repository.InTx(ctx, h.db, &sql.TxOptions{Isolation: level}, func(ctx context.Context) error {
+			for i, op := range req.Operations {
+				res, err := h.execute(ctx, op)
+				if err != nil {
+					return operationError(i, err)
+				}
+				results = append(results, OperationResult{Index: i, Op: op.Op, Entity: op.Entity, Id: op.Id, Result: res})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	JSON(w, http.StatusOK, BatchResult{Results: results})
+}
+
+func (h *BatchHandler) execute(ctx context.Context, op Operation) (int64, error) {
+	entity, ok := h.entities[op.Entity]
+	if !ok {
+		return -1, apperror.Newf(apperror.BadRequest, "unknown entity %s", op.Entity)
+	}
+	if op.Op != OpInsert {
+		var err error
+		if ctx, err = IfMatch(ctx, op.IfMatch); err != nil {
+			return -1, err
+		}
+	}
+	switch op.Op {
+	case OpInsert, OpUpdate:
+		item := reflect.New(entity.Repository.Metadata.Type).Interface()
+		if err := json.Unmarshal(op.Body, item); err != nil {
+			return -1, apperror.Wrap(apperror.BadRequest, "invalid body", err)
+		}
+		if len(op.Id) > 0 && len(entity.Repository.Metadata.Keys) == 1 {
+			key := reflect.ValueOf(item).Elem().Field(entity.Repository.Metadata.Keys[0].Index)
+			if key.Kind() == reflect.String && len(key.String()) == 0 {
+				key.SetString(op.Id)
+			} else if entity.Repository.Key(item) != op.Id {
+				return -1, apperror.New(apperror.BadRequest, "Id not match")
+			}
+		}
+		if err := h.validator.Validate(ctx, item); err != nil {
+			return -1, err
+		}
+		if op.Op == OpInsert {
+			return entity.Insert(ctx, item)
+		}
+		return entity.Update(ctx, item)
+	case OpPatch:
+		if len(op.Id) == 0 || len(entity.Repository.Metadata.Keys) != 1 {
+			return -1, apperror.New(apperror.BadRequest, "Id cannot be empty")
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(op.Body, &body); err != nil {
+			return -1, apperror.Wrap(apperror.BadRequest, "invalid body", err)
+		}
+		key := entity.Repository.Metadata.Keys[0].Json
+		if id, ok := body[key]; ok && id != op.Id {
+			return -1, apperror.New(apperror.BadRequest, "Id not match")
+		}
+		body[key] = op.Id
+		item := reflect.New(entity.Repository.Metadata.Type).Interface()
+		if err := json.Unmarshal(op.Body, item); err != nil {
+			return -1, apperror.Wrap(apperror.BadRequest, "invalid body", err)
+		}
+		fields := make([]string, 0, len(body))
+		for field := range body {
+			if field != key {
+				fields = append(fields, field)
+			}
+		}
+		if err := h.validator.ValidatePartial(ctx, item, fields); err != nil {
+			return -1, err
+		}
+		return entity.Patch(ctx, body)
+	case OpDelete:
+		if len(op.Id) == 0 {
+			return -1, apperror.New(apperror.BadRequest, "Id cannot be empty")
+		}
+		return entity.Delete(ctx, op.Id)
+	}
+	return -1, apperror.Newf(apperror.BadRequest, "unknown op %s, use insert, update, patch or delete", op.Op)
+}
+
+// operationError keeps the code of err and points its details at the failing operation.
+func operationError(index int, err error) error {
+	e := apperror.As(err)
+	prefix := fmt.Sprintf("operations[%d]", index)
+	fields := make([]apperror.FieldError, 0, len(e.Fields)+1)
+	if len(e.Fields) == 0 {
+		fields = append(fields, apperror.FieldError{Field: prefix, Code: string(e.Code), Message: e.Message})
+	}
+	for _, f := range e.Fields {
+		f.Field = prefix + "." + f.Field
+		fields = append(fields, f)
+	}
+	return &apperror.Error{Code: e.Code, Message: fmt.Sprintf("operation %d failed: %s", index, e.Message), Fields: fields, Err: e.Err}
+}
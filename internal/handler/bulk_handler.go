@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/core-go/log"
+
+	"go-service/internal/bulk"
+	"go-service/internal/repository"
+	"go-service/internal/validation"
+)
+
+type BulkHandler struct {
+	importer   *bulk.Importer
+	repository *repository.Repository
+}
+
+func NewBulkHandler(repository *repository.Repository, insert func(context.Context, interface{}) (int64, error), validator *validation.Validator, config bulk.Config) *BulkHandler {
+	importer := &bulk.Importer{
+		DB:         repository.DB,
+		Repository: repository,
+		Insert:     insert,
+		Validate:   validator.Validate,
+		BatchSize:  config.BatchSize,
+		MaxRows:    config.MaxRows,
+	}
+	return &BulkHandler{importer: importer, repository: repository}
+}
+
+// Import reads a JSON array, NDJSON or CSV body, chosen by Content-Type. "mode=best_effort" keeps the valid rows,
+// the default "atomic" mode rolls back everything when any row fails. The report lists the outcome of every row.
+func (h *BulkHandler) Import(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	format, err := bulk.FromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	mode := bulk.Mode(r.URL.Query().Get("mode"))
+	if len(mode) == 0 {
+		mode = bulk.Atomic
+	} else if mode != bulk.Atomic && mode != bulk.BestEffort {
+		BadRequest(w, r, fmt.Sprintf("mode must be %s or %s", bulk.Atomic, bulk.BestEffort))
+		return
+	}
+	reader, err := bulk.NewReader(format, r.Body, h.repository.Metadata)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	report, err := h.importer.Import(r.Context(), reader, mode)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if !report.Committed {
+		status = http.StatusUnprocessableEntity
+	} else if report.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	JSON(w, status, report)
+}
+
+// Export streams every row as JSON, NDJSON or CSV, chosen by "format" or the Accept header.
+func (h *BulkHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format, err := bulk.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, h.repository.Table, format))
+	writer := bulk.NewWriter(format, w, h.repository.Metadata)
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	query := fmt.Sprintf("select %s from %s", h.repository.SelectColumns(), h.repository.Table)
+	if scope := h.repository.Scope(false); len(scope) > 0 {
+		query = query + " where " + scope
+	}
+	query = query + " order by " + strings.Join(h.repository.Metadata.KeyColumns(), ", ")
+	err = h.repository.Stream(repository.ReadOnly(r.Context()), func(item interface{}) error {
+		if err := writer.Write(item); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil && count%500 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	}, query)
+	if err != nil {
+		if count == 0 {
+			w.Header().Del("Content-Disposition")
+			Error(w, r, err)
+			return
+		}
+		log.Error(r.Context(), fmt.Sprintf("export of %s aborted after %d rows: %v", h.repository.Table, count, err))
+		return
+	}
+	writer.Close()
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/core-go/log"
+
+	"go-service/internal/apperror"
+	"go-service/internal/middleware"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 7807 error body returned by every handler.
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      apperror.Code         `json:"code"`
+	RequestId string                `json:"requestId,omitempty"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+}
+
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	e := apperror.As(err)
+	status := apperror.Status(e.Code)
+	detail := e.Message
+	if status >= http.StatusInternalServerError {
+		log.Error(r.Context(), err.Error())
+	}
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestId: middleware.GetRequestId(r.Context()),
+		Errors:    e.Fields,
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func BadRequest(w http.ResponseWriter, r *http.Request, message string) {
+	Error(w, r, apperror.New(apperror.BadRequest, message))
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+)
+
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseETags reads an If-Match or If-None-Match header. Weak tags are only accepted when weak is true,
+// as If-Match requires strong comparison.
+func ParseETags(header string, weak bool) (versions []int64, all bool, err error) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) == 0 {
+			continue
+		}
+		if tag == "*" {
+			all = true
+			continue
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, false, apperror.Newf(apperror.BadRequest, "invalid entity tag %s", tag)
+		}
+		version, er1 := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if er1 != nil {
+			// a tag this service never issued cannot match any version
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, all, nil
+}
+
+// IfMatch makes the writes done with the returned context conditional on the If-Match header, if present.
+func IfMatch(ctx context.Context, header string) (context.Context, error) {
+	if len(strings.TrimSpace(header)) == 0 {
+		return ctx, nil
+	}
+	versions, all, err := ParseETags(header, false)
+	if err != nil || all {
+		return ctx, err
+	}
+	if len(versions) == 0 {
+		return ctx, apperror.New(apperror.PreconditionFailed, "If-Match does not match any version")
+	}
+	return repository.IfMatch(ctx, versions...), nil
+}
+
+// NotModified writes the ETag of version and, when If-None-Match matches it, a 304 response.
+func NotModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+	w.Header().Set("ETag", ETag(version))
+	header := r.Header.Get("If-None-Match")
+	if len(header) == 0 {
+		return false
+	}
+	versions, all, err := ParseETags(header, true)
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		if v == version {
+			all = true
+		}
+	}
+	if all {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return all
+}
@@ -1,28 +1,41 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	sv "github.com/core-go/service"
 	"github.com/gorilla/mux"
 	. "go-service/internal/filter"
 	. "go-service/internal/model"
+	"go-service/internal/search"
 	. "go-service/internal/service"
+	"go-service/internal/validation"
 	"net/http"
 	"reflect"
 )
 
 type MovieHandler struct {
-	service MovieService
+	service   MovieService
+	validator *validation.Validator
 }
 
-func NewMovieHandler(service MovieService) *MovieHandler {
-	return &MovieHandler{service: service}
+func NewMovieHandler(service MovieService, validator *validation.Validator) *MovieHandler {
+	return &MovieHandler{service: service, validator: validator}
 }
 
 func (h *MovieHandler) All(w http.ResponseWriter, r *http.Request) {
+	if len(r.URL.RawQuery) > 0 {
+		var filter MovieFilter
+		if err := DecodeQuery(r.URL.Query(), &filter); err != nil {
+			Error(w, r, err)
+			return
+		}
+		h.search(w, r, filter, h.service.Search)
+		return
+	}
 	res, err := h.service.All(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -31,13 +44,16 @@ func (h *MovieHandler) All(w http.ResponseWriter, r *http.Request) {
 func (h *MovieHandler) Load(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 
 	res, err := h.service.Load(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
+		return
+	}
+	if NotModified(w, r, res.Version) {
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -48,12 +64,16 @@ func (h *MovieHandler) Insert(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&movie)
 	defer r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		BadRequest(w, r, err.Error())
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &movie); err != nil {
+		Error(w, r, err)
 		return
 	}
 	res, err := h.service.Insert(r.Context(), &movie)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -64,23 +84,32 @@ func (h *MovieHandler) Update(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&movie)
 	defer r.Body.Close()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		BadRequest(w, r, err.Error())
 		return
 	}
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 	if len(movie.Id) == 0 {
 		movie.Id = id
 	} else if id != movie.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+		BadRequest(w, r, "Id not match")
+		return
+	}
+	if err := h.validator.Validate(r.Context(), &movie); err != nil {
+		Error(w, r, err)
+		return
+	}
+	ctx, er3 := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if er3 != nil {
+		Error(w, r, er3)
 		return
 	}
-	res, er2 := h.service.Update(r.Context(), &movie)
+	res, er2 := h.service.Update(ctx, &movie)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		Error(w, r, er2)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -88,8 +117,8 @@ func (h *MovieHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 func (h *MovieHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	if len(id) != 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+	if len(id) == 0 {
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 
@@ -98,24 +127,37 @@ func (h *MovieHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	_, jsonMap, _ := sv.BuildMapField(movieType)
 	body, er1 := sv.BuildMapAndStruct(r, &movie)
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusInternalServerError)
+		BadRequest(w, r, er1.Error())
 		return
 	}
 	if len(movie.Id) == 0 {
 		movie.Id = id
 	} else if id != movie.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+		BadRequest(w, r, "Id not match")
 		return
 	}
 	json, er2 := sv.BodyToJsonMap(r, movie, body, []string{"id"}, jsonMap)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		Error(w, r, er2)
+		return
+	}
+	fields := make([]string, 0, len(json))
+	for field := range json {
+		fields = append(fields, field)
+	}
+	if err := h.validator.ValidatePartial(r.Context(), &movie, fields); err != nil {
+		Error(w, r, err)
 		return
 	}
 
-	res, er3 := h.service.Patch(r.Context(), json)
+	ctx, er4 := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if er4 != nil {
+		Error(w, r, er4)
+		return
+	}
+	res, er3 := h.service.Patch(ctx, json)
 	if er3 != nil {
-		http.Error(w, er3.Error(), http.StatusBadRequest)
+		Error(w, r, er3)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -124,12 +166,17 @@ func (h *MovieHandler) Patch(w http.ResponseWriter, r *http.Request) {
 func (h *MovieHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
-	res, err := h.service.Delete(r.Context(), id)
+	ctx, err := IfMatch(r.Context(), r.Header.Get("If-Match"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
+		return
+	}
+	res, err := h.service.Delete(ctx, id)
+	if err != nil {
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -139,14 +186,54 @@ func (h *MovieHandler) Search(w http.ResponseWriter, r *http.Request) {
 	var filter MovieFilter
 	err := json.NewDecoder(r.Body).Decode(&filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		BadRequest(w, r, err.Error())
+		return
+	}
+	h.search(w, r, filter, h.service.Search)
+}
+
+func (h *MovieHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	var filter MovieFilter
+	if err := DecodeQuery(r.URL.Query(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	h.search(w, r, filter, h.service.Trash)
+}
+
+func (h *MovieHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		BadRequest(w, r, "Id cannot be empty")
+		return
+	}
+	ctx, err := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if err != nil {
+		Error(w, r, err)
 		return
 	}
-	res, err := h.service.Search(r.Context(), filter)
+	res, err := h.service.Restore(ctx, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
+}
 
-}
\ No newline at end of file
+func (h *MovieHandler) search(w http.ResponseWriter, r *http.Request, filter MovieFilter, find func(context.Context, MovieFilter) (*ResultMovie, error)) {
+	if err := h.validator.Validate(r.Context(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	res, err := find(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	projected, err := search.Project(res, filter.Fields)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	JSON(w, http.StatusOK, projected)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/convert"
+)
+
+// DecodeQuery fills filter, a pointer to a struct, from query parameters named after its json tags.
+// Range structs are addressed as "<field>.min" and "<field>.max", slices accept repeated or comma separated values.
+// Unknown parameters and values that cannot be converted are reported together as a validation error.
+func DecodeQuery(values url.Values, filter interface{}) error {
+	v := reflect.ValueOf(filter).Elem()
+	t := v.Type()
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) > 0 && name != "-" {
+			fields[name] = i
+		}
+	}
+	var errs []apperror.FieldError
+	for key, raw := range values {
+		name, sub := key, ""
+		if i := strings.Index(key, "."); i >= 0 {
+			name, sub = key[:i], key[i+1:]
+		}
+		i, ok := fields[name]
+		if !ok {
+			errs = append(errs, apperror.FieldError{Field: key, Code: "unknown", Message: key + " is not a supported parameter"})
+			continue
+		}
+		target := v.Field(i)
+		if len(sub) > 0 {
+			target = subField(target, sub)
+			if !target.IsValid() {
+				errs = append(errs, apperror.FieldError{Field: key, Code: "unknown", Message: key + " is not a supported parameter"})
+				continue
+			}
+		}
+		if err := convert.Set(target, raw); err != nil {
+			errs = append(errs, apperror.FieldError{Field: key, Code: "type", Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return apperror.NewValidation(errs)
+	}
+	return nil
+}
+
+func subField(v reflect.Value, name string) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		if v.Type().Elem().Kind() != reflect.Struct || v.Type().Elem() == convert.TimeType {
+			return reflect.Value{}
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	sv "github.com/core-go/service"
 	"github.com/gorilla/mux"
@@ -9,21 +10,33 @@ import (
 
 	. "go-service/internal/filter"
 	. "go-service/internal/model"
+	"go-service/internal/search"
 	. "go-service/internal/service"
+	"go-service/internal/validation"
 )
 
 type UserHandler struct {
-	service UserService
+	service   UserService
+	validator *validation.Validator
 }
 
-func NewUserHandler(service UserService) *UserHandler {
-	return &UserHandler{service: service}
+func NewUserHandler(service UserService, validator *validation.Validator) *UserHandler {
+	return &UserHandler{service: service, validator: validator}
 }
 
 func (h *UserHandler) All(w http.ResponseWriter, r *http.Request) {
+	if len(r.URL.RawQuery) > 0 {
+		var filter UserFilter
+		if err := DecodeQuery(r.URL.Query(), &filter); err != nil {
+			Error(w, r, err)
+			return
+		}
+		h.search(w, r, filter, h.service.Search)
+		return
+	}
 	res, err := h.service.All(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -32,13 +45,16 @@ func (h *UserHandler) All(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Load(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 
 	res, err := h.service.Load(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
+		return
+	}
+	if NotModified(w, r, res.Version) {
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -49,13 +65,17 @@ func (h *UserHandler) Insert(w http.ResponseWriter, r *http.Request) {
 	er1 := json.NewDecoder(r.Body).Decode(&user)
 	defer r.Body.Close()
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusBadRequest)
+		BadRequest(w, r, er1.Error())
 		return
 	}
 
+	if err := h.validator.Validate(r.Context(), &user); err != nil {
+		Error(w, r, err)
+		return
+	}
 	res, er2 := h.service.Insert(r.Context(), &user)
 	if er2 != nil {
-		http.Error(w, er1.Error(), http.StatusInternalServerError)
+		Error(w, r, er2)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -66,24 +86,33 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	er1 := json.NewDecoder(r.Body).Decode(&user)
 	defer r.Body.Close()
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusBadRequest)
+		BadRequest(w, r, er1.Error())
 		return
 	}
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 	if len(user.Id) == 0 {
 		user.Id = id
 	} else if id != user.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+		BadRequest(w, r, "Id not match")
 		return
 	}
 
-	res, er2 := h.service.Update(r.Context(), &user)
+	if err := h.validator.Validate(r.Context(), &user); err != nil {
+		Error(w, r, err)
+		return
+	}
+	ctx, er3 := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if er3 != nil {
+		Error(w, r, er3)
+		return
+	}
+	res, er2 := h.service.Update(ctx, &user)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		Error(w, r, er2)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -92,7 +121,7 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
 
@@ -101,24 +130,37 @@ func (h *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	_, jsonMap, _ := sv.BuildMapField(userType)
 	body, er1 := sv.BuildMapAndStruct(r, &user)
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusInternalServerError)
+		BadRequest(w, r, er1.Error())
 		return
 	}
 	if len(user.Id) == 0 {
 		user.Id = id
 	} else if id != user.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+		BadRequest(w, r, "Id not match")
 		return
 	}
 	json, er2 := sv.BodyToJsonMap(r, user, body, []string{"id"}, jsonMap)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		Error(w, r, er2)
+		return
+	}
+	fields := make([]string, 0, len(json))
+	for field := range json {
+		fields = append(fields, field)
+	}
+	if err := h.validator.ValidatePartial(r.Context(), &user, fields); err != nil {
+		Error(w, r, err)
 		return
 	}
 
-	res, er3 := h.service.Patch(r.Context(), json)
+	ctx, er4 := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if er4 != nil {
+		Error(w, r, er4)
+		return
+	}
+	res, er3 := h.service.Patch(ctx, json)
 	if er3 != nil {
-		http.Error(w, er3.Error(), http.StatusInternalServerError)
+		Error(w, r, er3)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -127,12 +169,17 @@ func (h *UserHandler) Patch(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		BadRequest(w, r, "Id cannot be empty")
 		return
 	}
-	res, err := h.service.Delete(r.Context(), id)
+	ctx, err := IfMatch(r.Context(), r.Header.Get("If-Match"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
+		return
+	}
+	res, err := h.service.Delete(ctx, id)
+	if err != nil {
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
@@ -142,17 +189,58 @@ func (h *UserHandler) Search(w http.ResponseWriter, r *http.Request) {
 	var filter UserFilter
 	err := json.NewDecoder(r.Body).Decode(&filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		BadRequest(w, r, err.Error())
 		return
 	}
-	res, err := h.service.Search(r.Context(), filter)
+	h.search(w, r, filter, h.service.Search)
+}
+
+func (h *UserHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	var filter UserFilter
+	if err := DecodeQuery(r.URL.Query(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	h.search(w, r, filter, h.service.Trash)
+}
+
+func (h *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		BadRequest(w, r, "Id cannot be empty")
+		return
+	}
+	ctx, err := IfMatch(r.Context(), r.Header.Get("If-Match"))
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	res, err := h.service.Restore(ctx, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 	JSON(w, http.StatusOK, res)
 }
 
+func (h *UserHandler) search(w http.ResponseWriter, r *http.Request, filter UserFilter, find func(context.Context, UserFilter) (*Result, error)) {
+	if err := h.validator.Validate(r.Context(), &filter); err != nil {
+		Error(w, r, err)
+		return
+	}
+	res, err := find(r.Context(), filter)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	projected, err := search.Project(res, filter.Fields)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	JSON(w, http.StatusOK, projected)
+}
+
 func JSON(w http.ResponseWriter, code int, res interface{}) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
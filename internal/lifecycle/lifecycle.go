@@ -0,0 +1,186 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/core-go/log"
+)
+
+type Config struct {
+	// Delay is how many seconds /health reports the service down before the server stops accepting connections,
+	// so that load balancers stop routing to it first.
+	Delay int64 `mapstructure:"delay"`
+	// Timeout bounds, in seconds, the draining of in-flight requests and the stop hooks that follow.
+	Timeout int64 `mapstructure:"timeout"`
+}
+
+// Hook is a component started before the server accepts requests and stopped after it has drained.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in the order they were appended and stops them in reverse, so a component
+// is stopped before whatever it was built on, such as the DB pool.
+type Lifecycle struct {
+	config   Config
+	mu       sync.Mutex
+	hooks    []Hook
+	started  int
+	draining int32
+}
+
+func New(c Config) *Lifecycle {
+	return &Lifecycle{config: c}
+}
+
+func (l *Lifecycle) Append(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, h)
+}
+
+// Go runs worker in its own goroutine from Start until Stop, which cancels its context and waits for it to return.
+func (l *Lifecycle) Go(name string, worker func(ctx context.Context)) {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	l.Append(Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			var wctx context.Context
+			wctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				worker(wctx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Start runs the start hooks. When one fails, the hooks already started are stopped again.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks[l.started:]
+	l.mu.Unlock()
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			if err := h.OnStart(ctx); err != nil {
+				l.Stop(ctx)
+				return errors.New(h.Name + ": " + err.Error())
+			}
+		}
+		l.mu.Lock()
+		l.started++
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of the started components in reverse order. Every hook runs even when one fails.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks[:l.started]
+	l.started = 0
+	l.mu.Unlock()
+	var failed []string
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if h := hooks[i]; h.OnStop != nil {
+			if err := h.OnStop(ctx); err != nil {
+				failed = append(failed, h.Name+": "+err.Error())
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (l *Lifecycle) Draining() bool {
+	return atomic.LoadInt32(&l.draining) == 1
+}
+
+// Name, Check and Build make the Lifecycle a health checker that fails from the moment shutdown begins.
+func (l *Lifecycle) Name() string {
+	return "lifecycle"
+}
+
+func (l *Lifecycle) Check(ctx context.Context) (map[string]interface{}, error) {
+	if l.Draining() {
+		return nil, errors.New("shutting down")
+	}
+	return nil, nil
+}
+
+func (l *Lifecycle) Build(ctx context.Context, data map[string]interface{}, err error) map[string]interface{} {
+	if err == nil {
+		return data
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["error"] = err.Error()
+	return data
+}
+
+// Run starts the hooks and serves until SIGINT or SIGTERM. It then reports unhealthy for Delay seconds,
+// drains in-flight requests and runs the stop hooks, within Timeout seconds.
+func (l *Lifecycle) Run(ctx context.Context, server *http.Server) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var err error
+	select {
+	case err = <-errs:
+	case s := <-signals:
+		log.Info(ctx, "received "+s.String()+", shutting down")
+		atomic.StoreInt32(&l.draining, 1)
+		time.Sleep(time.Duration(l.config.Delay) * time.Second)
+	}
+	timeout := time.Duration(l.config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	sctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err == nil {
+		if err = server.Shutdown(sctx); err != nil {
+			log.Error(ctx, "server shutdown: "+err.Error())
+		}
+	}
+	if er2 := l.Stop(sctx); er2 != nil {
+		log.Error(ctx, "stop hooks: "+er2.Error())
+		if err == nil {
+			err = er2
+		}
+	}
+	return err
+}
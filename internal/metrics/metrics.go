@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+)
+
+const DefaultPath = "/metrics"
+
+type Config struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+	// Buckets are the upper bounds, in seconds, of the latency histograms.
+	Buckets []float64 `mapstructure:"buckets"`
+}
+
+// Metrics holds the collectors of the service in its own registry, next to the Go runtime and process collectors.
+type Metrics struct {
+	enabled  bool
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	queries  *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+}
+
+func NewMetrics(c Config) *Metrics {
+	buckets := c.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	m := &Metrics{
+		enabled:  c.Enabled,
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route template.",
+			Buckets: buckets,
+		}, []string{"method", "route"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "service_query_duration_seconds",
+			Help:    "Latency of the service methods by query name and error code.",
+			Buckets: buckets,
+		}, []string{"query", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "service_query_retries_total",
+			Help: "Retries of the service methods by query name and transient error reason.",
+		}, []string{"query", "reason"}),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.requests, m.duration, m.queries, m.retries,
+	)
+	return m
+}
+
+// RegisterDB exposes the pool statistics of db, labelled with name.
+func (m *Metrics) RegisterDB(name string, db *sql.DB) {
+	m.registry.MustRegister(newDBStatsCollector(name, db))
+}
+
+// RegisterStatements exposes the hit, miss and eviction counts of the prepared statement cache of the named pool.
+func (m *Metrics) RegisterStatements(name string, statements *repository.Statements) {
+	m.registry.MustRegister(newStatementCollector(name, statements))
+}
+
+// Register adds collectors of other components to the registry.
+func (m *Metrics) Register(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// Serve writes the metrics in the Prometheus text format.
+func (m *Metrics) Serve(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// Handler is the router middleware that counts and times requests by route template,
+// such as "/users/{id}", so that ids do not explode the number of series.
+func (m *Metrics) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(sw.status)).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveQuery records the latency of the service method name, which started at start and returned err.
+func (m *Metrics) ObserveQuery(name string, start time.Time, err error) {
+	if !m.enabled {
+		return
+	}
+	code := "ok"
+	if err != nil {
+		code = string(apperror.As(err).Code)
+	}
+	m.queries.WithLabelValues(name, code).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRetry counts one retry of the service method name after a transient error of the given reason.
+func (m *Metrics) ObserveRetry(name string, reason string) {
+	if !m.enabled {
+		return
+	}
+	m.retries.WithLabelValues(name, reason).Inc()
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go-service/internal/repository"
+)
+
+// statementCollector reads the prepared statement cache statistics once per scrape.
+type statementCollector struct {
+	statements *repository.Statements
+	size       *prometheus.Desc
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	reprepares *prometheus.Desc
+	evictions  *prometheus.Desc
+}
+
+func newStatementCollector(name string, statements *repository.Statements) *statementCollector {
+	labels := prometheus.Labels{"db_name": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("go_sql_statements_"+metric, help, nil, labels)
+	}
+	return &statementCollector{
+		statements: statements,
+		size:       desc("cached", "The number of prepared statements in the cache."),
+		hits:       desc("hits_total", "The total number of statements found in the cache."),
+		misses:     desc("misses_total", "The total number of statements prepared on a cache miss."),
+		reprepares: desc("reprepares_total", "The total number of statements prepared again after the database invalidated them."),
+		evictions:  desc("evictions_total", "The total number of statements closed to make room in the cache."),
+	}
+}
+
+func (c *statementCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.reprepares
+	ch <- c.evictions
+}
+
+func (c *statementCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.statements.Stats()
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.reprepares, prometheus.CounterValue, float64(stats.Reprepares))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	RequestIdHeader = "X-Request-Id"
+	RequestId       = "requestId"
+)
+
+// RequestIdHandler reuses the caller's X-Request-Id or generates one, echoes it in the response
+// and stores it in the context under "requestId", so core-go/log can add it to log fields.
+func RequestIdHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if len(id) == 0 || len(id) > 128 {
+			id = newRequestId()
+		}
+		w.Header().Set(RequestIdHeader, id)
+		ctx := context.WithValue(r.Context(), RequestId, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetRequestId(ctx context.Context) string {
+	id, _ := ctx.Value(RequestId).(string)
+	return id
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+
+	"go-service/internal/dialect"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		script  string
+		want    []string
+	}{
+		{"statements", dialect.Postgres, "create table a (id int);\n\ncreate table b (id int);\n", []string{"create table a (id int)", "create table b (id int)"}},
+		{"semicolons in quotes", dialect.Postgres, "insert into a values ('x;y');", []string{"insert into a values ('x;y')"}},
+		{"dash comments", dialect.Postgres, "-- one; two\nselect 1;", []string{"select 1"}},
+		{"block comments", dialect.SQLServer, "/* one; two */select 1;", []string{"select 1"}},
+		{"hash comments on mysql", dialect.MySQL, "# one; two\nselect 1;", []string{"select 1"}},
+		{"hash in quotes on mysql", dialect.MySQL, "insert into a values ('#1;2');", []string{"insert into a values ('#1;2')"}},
+		{"hash operator elsewhere", dialect.Postgres, "select data #> '{a,b}' from a; select 1;", []string{"select data #> '{a,b}' from a", "select 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.script, tt.dialect); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
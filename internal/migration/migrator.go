@@ -0,0 +1,331 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-service/internal/dialect"
+)
+
+const (
+	DefaultDir         = "migrations"
+	DefaultTable       = "schema_migrations"
+	DefaultLock        = "schema_migrations"
+	DefaultLockTimeout = 30
+)
+
+// querier is a *sql.DB or *sql.Conn.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Migrator applies the migrations of one directory, which are read and checksummed once by NewMigrator.
+type Migrator struct {
+	DB          *sql.DB
+	Dialect     *dialect.Dialect
+	Migrations  []Migration
+	Table       string
+	Lock        string
+	LockTimeout int64
+}
+
+func NewMigrator(db *sql.DB, c Config) (*Migrator, error) {
+	dir := c.Dir
+	if len(dir) == 0 {
+		dir = DefaultDir
+	}
+	d := dialect.Of(db)
+	// Scripts written for one database live in a subdirectory named after its dialect, such as "migrations/postgres".
+	if info, err := os.Stat(filepath.Join(dir, d.Name)); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, d.Name)
+	}
+	migrations, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{DB: db, Dialect: d, Migrations: migrations, Table: c.Table, Lock: c.Lock, LockTimeout: c.LockTimeout}
+	if len(m.Table) == 0 {
+		m.Table = DefaultTable
+	}
+	if len(m.Lock) == 0 {
+		m.Lock = DefaultLock
+	}
+	if m.LockTimeout <= 0 {
+		m.LockTimeout = DefaultLockTimeout
+	}
+	return m, nil
+}
+
+type record struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Up applies every pending migration in order and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.pending(records) {
+			if err := m.apply(ctx, conn, mg, true); err != nil {
+				return err
+			}
+			applied = append(applied, mg)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(records) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mg, _ := m.find(records[i].Version)
+			if err := m.apply(ctx, conn, mg, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, mg)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Redo reverts and re-applies the latest applied migration.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		mg, _ := m.find(records[len(records)-1].Version)
+		if err := m.apply(ctx, conn, mg, false); err != nil {
+			return err
+		}
+		if err := m.apply(ctx, conn, mg, true); err != nil {
+			return err
+		}
+		redone = &mg
+		return nil
+	})
+	return redone, err
+}
+
+// Status lists every known migration, plus applied versions whose files are missing, in version order.
+// It creates the migrations table when there is none.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return m.status(records), nil
+}
+
+// Inspect is Status for callers that may only read, such as health checks: without a migrations table,
+// every migration is pending.
+func (m *Migrator) Inspect(ctx context.Context) ([]Status, error) {
+	records, err := m.records(ctx, m.DB)
+	if err != nil {
+		exists, er2 := m.Dialect.HasTable(ctx, m.DB, m.Table)
+		if er2 != nil || exists {
+			return nil, err
+		}
+		records = nil
+	}
+	return m.status(records), nil
+}
+
+func (m *Migrator) status(records []record) []Status {
+	applied := make(map[int64]record)
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	var statuses []Status
+	for _, mg := range m.Migrations {
+		s := Status{Version: mg.Version, Name: mg.Name}
+		if r, ok := applied[mg.Version]; ok {
+			appliedAt := r.AppliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+			s.Modified = r.Checksum != mg.Checksum
+			delete(applied, mg.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range records {
+		if _, ok := applied[r.Version]; ok {
+			appliedAt := r.AppliedAt
+			statuses = append(statuses, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+		}
+	}
+	return statuses
+}
+
+// Pending reports whether any migration has not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) (bool, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, f func(*sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	release, err := m.Dialect.Lock(ctx, conn, m.Lock, m.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err = m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	query := m.Dialect.CreateTable(m.Table, fmt.Sprintf(`
+	  version bigint not null,
+	  name varchar(255) not null,
+	  checksum varchar(64) not null,
+	  applied_at %s not null,
+	  primary key (version)
+	`, m.Dialect.Timestamp()))
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+func (m *Migrator) records(ctx context.Context, q querier) ([]record, error) {
+	query := fmt.Sprintf("select version, name, checksum, applied_at from %s order by version", m.Table)
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []record
+	for rows.Next() {
+		var r record
+		if err = rows.Scan(&r.Version, &r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// verify refuses to run when an applied migration was edited or removed, or when a pending migration is older than the latest applied one.
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) ([]record, error) {
+	records, err := m.records(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var latest int64
+	for _, r := range records {
+		mg, ok := m.find(r.Version)
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d_%s not found in migration files", r.Version, r.Name)
+		}
+		if mg.Checksum != r.Checksum {
+			return nil, fmt.Errorf("migration %d_%s was modified after it was applied", mg.Version, mg.Name)
+		}
+		latest = r.Version
+	}
+	for _, mg := range m.pending(records) {
+		if mg.Version < latest {
+			return nil, fmt.Errorf("migration %d_%s is older than the latest applied version %d", mg.Version, mg.Name, latest)
+		}
+	}
+	return records, nil
+}
+
+func (m *Migrator) pending(records []record) []Migration {
+	applied := make(map[int64]bool)
+	for _, r := range records {
+		applied[r.Version] = true
+	}
+	var pending []Migration
+	for _, mg := range m.Migrations {
+		if !applied[mg.Version] {
+			pending = append(pending, mg)
+		}
+	}
+	return pending
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, mg := range m.Migrations {
+		if mg.Version == version {
+			return mg, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg Migration, up bool) error {
+	script := mg.Up
+	if !up {
+		script = mg.Down
+		if len(script) == 0 {
+			return fmt.Errorf("migration %d_%s has no down script", mg.Version, mg.Name)
+		}
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range Split(script, m.Dialect.Name) {
+		if _, err = tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s: %v", mg.Version, mg.Name, err)
+		}
+	}
+	if up {
+		p := m.Dialect.Param
+		query := fmt.Sprintf("insert into %s (version, name, checksum, applied_at) values (%s, %s, %s, %s)", m.Table, p(1), p(2), p(3), p(4))
+		_, err = tx.ExecContext(ctx, query, mg.Version, mg.Name, mg.Checksum, time.Now().UTC())
+	} else {
+		query := fmt.Sprintf("delete from %s where version = %s", m.Table, m.Dialect.Param(1))
+		_, err = tx.ExecContext(ctx, query, mg.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,7 +1,12 @@
 package model
 
+import "time"
+
 type Movie struct {
-	Id      string `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" validate:"required,max=40"`
-	Name    string `json:"name" gorm:"column:name" bson:"name" dynamodbav:"name" firestore:"name" validate:"required,name,max=100"`
-	Watched bool   `json:"watched" validate:"required"`
+	Id        string     `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" validate:"required,max=40"`
+	Name      string     `json:"name" gorm:"column:name" bson:"name" dynamodbav:"name" firestore:"name" validate:"required,name,max=100"`
+	Watched   bool       `json:"watched" gorm:"column:watched" bson:"watched" dynamodbav:"watched" firestore:"watched"`
+	Version   int64      `json:"version" gorm:"column:version" bson:"version" dynamodbav:"version" firestore:"version" version:"true"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" gorm:"column:deleted_at" bson:"deletedAt,omitempty" dynamodbav:"deletedAt,omitempty" firestore:"deletedAt,omitempty" deleted:"at"`
+	DeletedBy *string    `json:"deletedBy,omitempty" gorm:"column:deleted_by" bson:"deletedBy,omitempty" dynamodbav:"deletedBy,omitempty" firestore:"deletedBy,omitempty" deleted:"by"`
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type AuditLog struct {
+	Id        int64     `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id"`
+	Entity    string    `json:"entity" gorm:"column:entity" bson:"entity" dynamodbav:"entity" firestore:"entity"`
+	EntityId  string    `json:"entityId" gorm:"column:entity_id" bson:"entityId" dynamodbav:"entityId" firestore:"entityId"`
+	Operation string    `json:"operation" gorm:"column:operation" bson:"operation" dynamodbav:"operation" firestore:"operation"`
+	Actor     *string   `json:"actor,omitempty" gorm:"column:actor" bson:"actor,omitempty" dynamodbav:"actor,omitempty" firestore:"actor,omitempty"`
+	RequestId *string   `json:"requestId,omitempty" gorm:"column:request_id" bson:"requestId,omitempty" dynamodbav:"requestId,omitempty" firestore:"requestId,omitempty"`
+	Changes   RawJSON   `json:"changes" gorm:"column:changes" bson:"changes" dynamodbav:"changes" firestore:"changes"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at" bson:"createdAt" dynamodbav:"createdAt" firestore:"createdAt"`
+}
+
+// RawJSON is raw JSON kept in a text column, which MySQL returns as bytes and the other drivers as a string.
+type RawJSON json.RawMessage
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(j).MarshalJSON()
+}
+
+func (j *RawJSON) UnmarshalJSON(data []byte) error {
+	return (*json.RawMessage)(j).UnmarshalJSON(data)
+}
+
+func (j *RawJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(RawJSON(nil), v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", src)
+	}
+	return nil
+}
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Credential holds the password of a user. It lives in its own table and is never serialized.
+type Credential struct {
+	UserId         string     `json:"-" gorm:"column:user_id;primary_key"`
+	PasswordHash   string     `json:"-" gorm:"column:password_hash"`
+	Roles          *string    `json:"-" gorm:"column:roles"`
+	FailedAttempts int        `json:"-" gorm:"column:failed_attempts"`
+	LockedUntil    *time.Time `json:"-" gorm:"column:locked_until"`
+	UpdatedAt      time.Time  `json:"-" gorm:"column:updated_at"`
+}
+
+// RefreshToken is stored by the SHA-256 of the token. Tokens rotated from the same login share a family.
+type RefreshToken struct {
+	Id         string     `json:"-" gorm:"column:id;primary_key"`
+	UserId     string     `json:"-" gorm:"column:user_id"`
+	Family     string     `json:"-" gorm:"column:family"`
+	ExpiresAt  time.Time  `json:"-" gorm:"column:expires_at"`
+	CreatedAt  time.Time  `json:"-" gorm:"column:created_at"`
+	RevokedAt  *time.Time `json:"-" gorm:"column:revoked_at"`
+	ReplacedBy *string    `json:"-" gorm:"column:replaced_by"`
+}
+
+type Login struct {
+	Username string `json:"username" validate:"required,max=120"`
+	Password string `json:"password" validate:"required,max=1024"`
+}
+
+type Refresh struct {
+	RefreshToken string `json:"refreshToken" validate:"required,max=200"`
+}
+
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword,omitempty" validate:"max=1024"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=1024"`
+}
+
+type Token struct {
+	AccessToken      string `json:"accessToken"`
+	TokenType        string `json:"tokenType"`
+	ExpiresIn        int64  `json:"expiresIn"`
+	RefreshToken     string `json:"refreshToken"`
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"`
+}
@@ -8,4 +8,7 @@ type User struct {
 	Email       string     `json:"email" gorm:"column:email" bson:"email" dynamodbav:"email" firestore:"email" validate:"email,max=100"`
 	Phone       string     `json:"phone" gorm:"column:phone" bson:"phone" dynamodbav:"phone" firestore:"phone" validate:"required,phone,max=18"`
 	DateOfBirth *time.Time `json:"dateOfBirth" gorm:"column:date_of_birth" bson:"dateOfBirth" dynamodbav:"dateOfBirth" firestore:"dateOfBirth"`
+	Version     int64      `json:"version" gorm:"column:version" bson:"version" dynamodbav:"version" firestore:"version" version:"true"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty" gorm:"column:deleted_at" bson:"deletedAt,omitempty" dynamodbav:"deletedAt,omitempty" firestore:"deletedAt,omitempty" deleted:"at"`
+	DeletedBy   *string    `json:"deletedBy,omitempty" gorm:"column:deleted_by" bson:"deletedBy,omitempty" dynamodbav:"deletedBy,omitempty" firestore:"deletedBy,omitempty" deleted:"by"`
 }
@@ -0,0 +1,162 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/core-go/health"
+)
+
+const DefaultTimeout = 3000
+
+type Config struct {
+	// Timeout is the default time limit of one check, in milliseconds.
+	Timeout int64                  `mapstructure:"timeout"`
+	Checks  map[string]CheckConfig `mapstructure:"checks"`
+	// MaxPoolUsage is the share of the DB pool's max open connections in use above which the pool check fails.
+	MaxPoolUsage float64 `mapstructure:"max_pool_usage"`
+}
+
+type CheckConfig struct {
+	Timeout int64 `mapstructure:"timeout"`
+}
+
+type Result struct {
+	Status  string                 `json:"status"`
+	Latency float64                `json:"latencyMs"`
+	Error   string                 `json:"error,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+type Health struct {
+	Status  string            `json:"status"`
+	Details map[string]Result `json:"details,omitempty"`
+}
+
+// Handler serves the liveness and readiness probes. Any component can add the checkers of its dependencies;
+// liveness checkers are also part of readiness.
+type Handler struct {
+	config Config
+	mu     sync.RWMutex
+	live   []health.Checker
+	ready  []health.Checker
+}
+
+func NewHandler(c Config) *Handler {
+	return &Handler{config: c}
+}
+
+// AddLive adds checkers whose failure means the process must be restarted.
+func (h *Handler) AddLive(checkers ...health.Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.live = append(h.live, checkers...)
+	h.ready = append(h.ready, checkers...)
+}
+
+// AddReady adds checkers whose failure means the process must not receive traffic for now.
+func (h *Handler) AddReady(checkers ...health.Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = append(h.ready, checkers...)
+}
+
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checkers := h.live
+	h.mu.RUnlock()
+	h.respond(w, h.Check(r.Context(), checkers))
+}
+
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checkers := h.ready
+	h.mu.RUnlock()
+	h.respond(w, h.Check(r.Context(), checkers))
+}
+
+func (h *Handler) respond(w http.ResponseWriter, result Health) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if result.Status == health.StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// Check runs the checkers concurrently, each within its own timeout, and is down when any of them is.
+func (h *Handler) Check(ctx context.Context, checkers []health.Checker) Health {
+	results := make([]Result, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c health.Checker) {
+			defer wg.Done()
+			results[i] = h.check(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	result := Health{Status: health.StatusUp}
+	if len(checkers) > 0 {
+		result.Details = make(map[string]Result, len(checkers))
+	}
+	for i, c := range checkers {
+		if results[i].Status == health.StatusDown {
+			result.Status = health.StatusDown
+		}
+		result.Details[c.Name()] = results[i]
+	}
+	return result
+}
+
+type outcome struct {
+	data map[string]interface{}
+	err  error
+}
+
+func (h *Handler) check(ctx context.Context, c health.Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout(c.Name()))
+	defer cancel()
+	start := time.Now()
+	done := make(chan outcome, 1)
+	go func() {
+		data, err := c.Check(ctx)
+		done <- outcome{data: data, err: err}
+	}()
+	var o outcome
+	select {
+	case o = <-done:
+	case <-ctx.Done():
+		o.err = errors.New("timed out after " + h.Timeout(c.Name()).String())
+	}
+	r := Result{Status: health.StatusUp, Latency: float64(time.Since(start).Microseconds()) / 1000, Data: o.data}
+	if o.err != nil {
+		r.Status = health.StatusDown
+		r.Error = o.err.Error()
+		if o.data != nil {
+			r.Data = c.Build(ctx, o.data, o.err)
+		}
+	}
+	if len(r.Data) == 0 {
+		r.Data = nil
+	}
+	return r
+}
+
+// Timeout returns the time limit of the check called name.
+func (h *Handler) Timeout(name string) time.Duration {
+	timeout := h.config.Checks[name].Timeout
+	if timeout <= 0 {
+		timeout = h.config.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return time.Duration(timeout) * time.Millisecond
+}
@@ -0,0 +1,45 @@
+package probe
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PoolChecker fails when the connections in use reach maxUsage of the pool's max open connections.
+// Pools without a limit never fail.
+type PoolChecker struct {
+	db       *sql.DB
+	name     string
+	maxUsage float64
+}
+
+func NewPoolChecker(db *sql.DB, maxUsage float64) *PoolChecker {
+	if maxUsage <= 0 {
+		maxUsage = 1
+	}
+	return &PoolChecker{db: db, name: "pool", maxUsage: maxUsage}
+}
+
+func (c *PoolChecker) Name() string {
+	return c.name
+}
+
+func (c *PoolChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	stats := c.db.Stats()
+	data := map[string]interface{}{
+		"maxOpen":   stats.MaxOpenConnections,
+		"open":      stats.OpenConnections,
+		"inUse":     stats.InUse,
+		"idle":      stats.Idle,
+		"waitCount": stats.WaitCount,
+	}
+	if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= c.maxUsage*float64(stats.MaxOpenConnections) {
+		return data, fmt.Errorf("%d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+	}
+	return data, nil
+}
+
+func (c *PoolChecker) Build(ctx context.Context, data map[string]interface{}, err error) map[string]interface{} {
+	return data
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit refills Requests tokens every Period seconds into a bucket holding at most Burst tokens.
+type Limit struct {
+	Requests float64 `mapstructure:"requests"`
+	Period   float64 `mapstructure:"period"`
+	Burst    float64 `mapstructure:"burst"`
+}
+
+func (l Limit) rate() float64 {
+	if l.Period <= 0 {
+		return l.Requests
+	}
+	return l.Requests / l.Period
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst <= 0 {
+		return math.Max(l.Requests, 1)
+	}
+	return l.Burst
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take spends one token of the bucket of key, if it has one.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take refills a bucket last seen at updated with tokens left, then spends one token from it.
+func take(tokens float64, updated time.Time, limit Limit, now time.Time) (float64, Result) {
+	rate, burst := limit.rate(), limit.burst()
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*rate)
+	}
+	r := Result{Limit: int64(burst)}
+	if tokens >= 1 {
+		tokens--
+		r.Allowed = true
+	} else if rate > 0 {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	r.Remaining = int64(tokens)
+	if rate > 0 {
+		r.Reset = seconds((burst - tokens) / rate)
+	}
+	return tokens, r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+// idle reports how long a bucket takes to refill completely, after which it can be forgotten.
+func idle(limit Limit) time.Duration {
+	if rate := limit.rate(); rate > 0 {
+		return seconds(limit.burst() / rate)
+	}
+	return time.Hour
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/core-go/log"
+
+	"go-service/internal/apperror"
+	"go-service/internal/auth"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendSQL    = "sql"
+)
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Backend is "memory" for one replica, or "sql" to share the buckets of every replica through the rate_limits table.
+	Backend string `mapstructure:"backend"`
+	// TrustForwarded keys anonymous clients by the first X-Forwarded-For address, for servers behind a proxy.
+	TrustForwarded bool `mapstructure:"trust_forwarded"`
+	// IP limits every request by address before it is authenticated, so that guessing credentials is limited too.
+	IP      Limit  `mapstructure:"ip"`
+	Default Limit  `mapstructure:"default"`
+	Routes  []Rule `mapstructure:"routes"`
+}
+
+// Rule sets the Limit of the routes under Path, "*" matching one path segment. The first matching rule wins.
+type Rule struct {
+	Methods []string `mapstructure:"methods"`
+	Path    string   `mapstructure:"path"`
+	Limit   `mapstructure:",squash"`
+}
+
+func (r Rule) match(method, path string) bool {
+	if len(r.Methods) > 0 && !contains(r.Methods, method) {
+		return false
+	}
+	return auth.MatchPath(r.Path, path)
+}
+
+// Limiter is the router middleware giving each client a token bucket per route rule. Clients are keyed by
+// their API key or user when authenticated, by IP address otherwise. Every address also has one bucket of its
+// own, taken before authentication.
+type Limiter struct {
+	config Config
+	store  Store
+	now    func() time.Time
+	Error  func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewLimiter(c Config, db *sql.DB, onError func(w http.ResponseWriter, r *http.Request, err error)) (*Limiter, error) {
+	l := &Limiter{config: c, now: time.Now, Error: onError}
+	switch strings.ToLower(c.Backend) {
+	case "", BackendMemory:
+		l.store = NewMemoryStore()
+	case BackendSQL, "mysql":
+		l.store = NewSQLStore(db, "rate_limits")
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend %s, use memory or sql", c.Backend)
+	}
+	return l, nil
+}
+
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.config.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		name, limit := l.limit(r.Method, r.URL.Path)
+		if l.take(w, r, name+"|"+l.client(r), limit) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// IPHandler applies the IP limit. It runs before authentication, which answers failed attempts without calling
+// the handlers after it.
+func (l *Limiter) IPHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.config.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if l.take(w, r, "ip|"+l.address(r), l.config.IP) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// take takes a token from the bucket key, and answers 429 and returns false when it is empty.
+func (l *Limiter) take(w http.ResponseWriter, r *http.Request, key string, limit Limit) bool {
+	if limit.Requests <= 0 {
+		return true
+	}
+	res, err := l.store.Take(r.Context(), key, limit, l.now())
+	if err != nil {
+		// A broken shared store must not take the whole API down with it.
+		log.Error(r.Context(), "rate limit: "+err.Error())
+		return true
+	}
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+	header.Set("RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+	header.Set("RateLimit-Reset", strconv.FormatInt(int64(res.Reset/time.Second), 10))
+	if !res.Allowed {
+		header.Set("Retry-After", strconv.FormatInt(int64(res.RetryAfter/time.Second), 10))
+		l.Error(w, r, apperror.Newf(apperror.RateLimited, "too many requests, retry in %d seconds", int64(res.RetryAfter/time.Second)))
+		return false
+	}
+	return true
+}
+
+// limit returns the bucket name and Limit of the first matching rule, or of the default.
+func (l *Limiter) limit(method, path string) (string, Limit) {
+	for i, rule := range l.config.Routes {
+		if rule.match(method, path) {
+			return "route" + strconv.Itoa(i), rule.Limit
+		}
+	}
+	return "default", l.config.Default
+}
+
+func (l *Limiter) client(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.Scheme + ":" + p.Subject
+	}
+	return l.address(r)
+}
+
+func (l *Limiter) address(r *http.Request) string {
+	if l.config.TrustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); len(forwarded) > 0 {
+			return "ip:" + strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-service/internal/dialect"
+	"go-service/internal/repository"
+)
+
+const cleanupInterval = 10 * time.Minute
+
+// SQLStore shares the buckets between replicas through the rate_limits table.
+// Each Take locks the row of its bucket for one short transaction.
+type SQLStore struct {
+	db      *sql.DB
+	table   string
+	dialect *dialect.Dialect
+	mu      sync.Mutex
+	cleaned time.Time
+}
+
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{db: db, table: table, dialect: dialect.Of(db)}
+}
+
+func (s *SQLStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.cleanup(ctx, now)
+	var r Result
+	err := repository.InTx(ctx, s.db, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(ctx context.Context) error {
+		tx, _ := repository.TxFromContext(ctx)
+		tokens, updated := limit.burst(), now
+		query := fmt.Sprintf("select tokens, updated_at from %s where bucket = %s%s",
+			s.dialect.From(s.table, true), s.dialect.Param(1), s.dialect.ForUpdate())
+		err := tx.QueryRowContext(ctx, query, key).Scan(&tokens, &updated)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		tokens, r = take(tokens, updated, limit, now)
+		query = s.dialect.Upsert(s.table, []string{"bucket"}, []string{"tokens", "updated_at"})
+		_, err = tx.ExecContext(ctx, query, key, tokens, now)
+		return err
+	})
+	return r, err
+}
+
+// cleanup deletes, at most every ten minutes, the buckets untouched for a day, which have long refilled.
+func (s *SQLStore) cleanup(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.cleaned) < cleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.cleaned = now
+	s.mu.Unlock()
+	query := fmt.Sprintf("delete from %s where updated_at < %s", s.table, s.dialect.Param(1))
+	s.db.ExecContext(ctx, query, now.Add(-24*time.Hour))
+}
@@ -0,0 +1,295 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/core-go/log"
+	csql "github.com/core-go/sql"
+
+	"go-service/internal/auth"
+	"go-service/internal/dialect"
+	"go-service/internal/repository"
+)
+
+const (
+	DefaultInterval = 5
+	HeaderLastWrite = "X-Last-Write"
+)
+
+type Node struct {
+	Name   string      `mapstructure:"name"`
+	Weight int         `mapstructure:"weight"`
+	Sql    csql.Config `mapstructure:",squash"`
+}
+
+// Config lists the replicas of the primary in sql. Interval, MaxLag and ReadYourWrites are in seconds; a replica
+// lagging more than MaxLag is skipped, and a client that wrote reads from the primary for ReadYourWrites.
+// 0 turns the lag limit or read-your-writes off.
+type Config struct {
+	Interval       int64  `mapstructure:"interval"`
+	MaxLag         int64  `mapstructure:"max_lag"`
+	ReadYourWrites int64  `mapstructure:"read_your_writes"`
+	Nodes          []Node `mapstructure:"nodes"`
+}
+
+type replica struct {
+	name       string
+	weight     int
+	current    int
+	db         *sql.DB
+	statements *repository.Statements
+	dialect    *dialect.Dialect
+	checked    bool
+	healthy    bool
+	lag        time.Duration
+	err        error
+}
+
+// Router sends read-only repository calls to healthy replicas by smooth weighted round-robin, and to the primary
+// when every replica is down or lagging. Replicas count as down until their first check.
+type Router struct {
+	config     Config
+	primary    *sql.DB
+	statements *repository.Statements
+	replicas   []*replica
+	mu         sync.Mutex
+	writes     map[string]time.Time
+	swept      time.Time
+	now        func() time.Time
+}
+
+func NewRouter(c Config, primary *sql.DB, statements *repository.Statements) *Router {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	return &Router{config: c, primary: primary, statements: statements, writes: make(map[string]time.Time), now: time.Now}
+}
+
+// Add registers a replica pool; weight defaults to 1.
+func (r *Router) Add(name string, weight int, db *sql.DB, statements *repository.Statements) {
+	if weight <= 0 {
+		weight = 1
+	}
+	r.replicas = append(r.replicas, &replica{name: name, weight: weight, db: db, statements: statements, dialect: dialect.Of(db)})
+}
+
+type primaryKey struct{}
+
+func (r *Router) Reader(ctx context.Context) (*sql.DB, *repository.Statements) {
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return r.primary, r.statements
+	}
+	if len(r.replicas) == 0 {
+		return r.primary, r.statements
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var chosen *replica
+	total := 0
+	for _, rep := range r.replicas {
+		if !rep.healthy {
+			continue
+		}
+		rep.current += rep.weight
+		total += rep.weight
+		if chosen == nil || rep.current > chosen.current {
+			chosen = rep
+		}
+	}
+	if chosen == nil {
+		return r.primary, r.statements
+	}
+	chosen.current -= total
+	return chosen.db, chosen.statements
+}
+
+// Handler gives clients read-your-writes consistency. A successful write stamps the response with X-Last-Write,
+// in unix milliseconds, and is remembered for the authenticated principal; later reads by that principal, or
+// carrying a recent X-Last-Write back, go to the primary. Failed writes change nothing and are not recorded.
+func (r *Router) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(r.replicas) == 0 || r.config.ReadYourWrites <= 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+		client, hasClient := principal(req)
+		if isRead(req) {
+			if r.wroteRecently(req, client, hasClient, r.now()) {
+				req = req.WithContext(context.WithValue(req.Context(), primaryKey{}, true))
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+		recorder := &writeRecorder{ResponseWriter: w, router: r, client: client, hasClient: hasClient}
+		next.ServeHTTP(recorder, req)
+		if !recorder.wroteHeader {
+			recorder.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+// isRead reports whether req only reads: safe methods, and the POST searches, which take their filter as a body.
+func isRead(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/search")
+	}
+	return false
+}
+
+// writeRecorder records the write of its request when the response status is 2xx, just before the header is sent.
+type writeRecorder struct {
+	http.ResponseWriter
+	router      *Router
+	client      string
+	hasClient   bool
+	wroteHeader bool
+}
+
+func (w *writeRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status >= 200 && status < 300 {
+			now := w.router.now()
+			w.Header().Set(HeaderLastWrite, strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10))
+			if w.hasClient {
+				w.router.remember(w.client, now)
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writeRecorder) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writeRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *Router) wroteRecently(req *http.Request, client string, hasClient bool, now time.Time) bool {
+	window := time.Duration(r.config.ReadYourWrites) * time.Second
+	if s := req.Header.Get(HeaderLastWrite); len(s) > 0 {
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil && now.Sub(time.Unix(0, ms*int64(time.Millisecond))) < window {
+			return true
+		}
+	}
+	if !hasClient {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[client]
+	return ok && now.Sub(at) < window
+}
+
+// remember records the write of client, dropping expired entries at most once per window.
+func (r *Router) remember(client string, now time.Time) {
+	window := time.Duration(r.config.ReadYourWrites) * time.Second
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[client] = now
+	if now.Sub(r.swept) < window {
+		return
+	}
+	for c, at := range r.writes {
+		if now.Sub(at) >= window {
+			delete(r.writes, c)
+		}
+	}
+	r.swept = now
+}
+
+func principal(req *http.Request) (string, bool) {
+	if p, ok := auth.FromContext(req.Context()); ok {
+		return p.Scheme + ":" + p.Subject, true
+	}
+	return "", false
+}
+
+// Run checks the replicas right away and then every Interval seconds until ctx is done.
+func (r *Router) Run(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	r.check(ctx)
+	ticker := time.NewTicker(time.Duration(r.config.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+// check pings each replica and measures its lag, logging every change of state.
+func (r *Router) check(ctx context.Context) {
+	for _, rep := range r.replicas {
+		c, cancel := context.WithTimeout(ctx, time.Duration(r.config.Interval)*time.Second)
+		var lag time.Duration
+		err := rep.db.PingContext(c)
+		if err == nil {
+			lag, err = rep.dialect.Lag(c, rep.db)
+		}
+		cancel()
+		if err == nil && r.config.MaxLag > 0 && lag > time.Duration(r.config.MaxLag)*time.Second {
+			err = fmt.Errorf("lag of %s exceeds %d seconds", lag, r.config.MaxLag)
+		}
+		r.mu.Lock()
+		changed := !rep.checked || rep.healthy != (err == nil)
+		rep.checked, rep.healthy, rep.lag, rep.err = true, err == nil, lag, err
+		r.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err != nil {
+			log.Error(ctx, fmt.Sprintf("replica %s is down, reads fall back to the primary: %v", rep.name, err))
+		} else {
+			log.Info(ctx, fmt.Sprintf("replica %s is up", rep.name))
+		}
+	}
+}
+
+func (r *Router) Name() string {
+	return "replicas"
+}
+
+// Check reports the state of every replica as of the last check. It never fails: reads fall back to the primary.
+func (r *Router) Check(ctx context.Context) (map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := make(map[string]interface{}, len(r.replicas))
+	for _, rep := range r.replicas {
+		state := map[string]interface{}{"status": "UP", "weight": rep.weight, "lagSeconds": rep.lag.Seconds()}
+		if !rep.healthy {
+			state["status"] = "DOWN"
+		}
+		if rep.err != nil {
+			state["error"] = rep.err.Error()
+		}
+		data[rep.name] = state
+	}
+	return data, nil
+}
+
+func (r *Router) Build(ctx context.Context, data map[string]interface{}, err error) map[string]interface{} {
+	return data
+}
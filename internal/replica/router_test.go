@@ -0,0 +1,90 @@
+package replica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-service/internal/auth"
+)
+
+func TestHandlerRecordsSuccessfulWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		write  bool
+	}{
+		{"insert", http.MethodPost, "/users", http.StatusCreated, true},
+		{"update", http.MethodPut, "/users/ironman", http.StatusOK, true},
+		{"delete without body", http.MethodDelete, "/users/ironman", 0, true},
+		{"restore", http.MethodPost, "/users/ironman/restore", http.StatusOK, true},
+		{"search", http.MethodPost, "/users/search", http.StatusOK, false},
+		{"movie search", http.MethodPost, "/movies/search/", http.StatusOK, false},
+		{"get", http.MethodGet, "/users/ironman", http.StatusOK, false},
+		{"invalid write", http.MethodPost, "/users", http.StatusBadRequest, false},
+		{"conflicting write", http.MethodPatch, "/users/ironman", http.StatusPreconditionFailed, false},
+		{"failed write", http.MethodDelete, "/users/ironman", http.StatusInternalServerError, false},
+	}
+	now := time.Unix(1700000000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter(Config{ReadYourWrites: 5}, nil, nil)
+			r.replicas = []*replica{{name: "replica", weight: 1, healthy: true}}
+			r.now = func() time.Time { return now }
+			var primary bool
+			handler := r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				primary, _ = req.Context().Value(primaryKey{}).(bool)
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			p := &auth.Principal{Subject: "tony", Scheme: auth.SchemeJWT}
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req.WithContext(auth.WithPrincipal(req.Context(), p)))
+			if stamped := len(rec.Header().Get(HeaderLastWrite)) > 0; stamped != tt.write {
+				t.Fatalf("got %s %q", HeaderLastWrite, rec.Header().Get(HeaderLastWrite))
+			}
+
+			req = httptest.NewRequest(http.MethodPost, "/users/search", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(auth.WithPrincipal(req.Context(), p)))
+			if primary != tt.write {
+				t.Fatalf("the next search read from the primary: %v", primary)
+			}
+		})
+	}
+}
+
+func TestHandlerHonoursLastWriteHeader(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewRouter(Config{ReadYourWrites: 5}, nil, nil)
+	r.replicas = []*replica{{name: "replica", weight: 1, healthy: true}}
+	r.now = func() time.Time { return now }
+	var primary bool
+	handler := r.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		primary, _ = req.Context().Value(primaryKey{}).(bool)
+	}))
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"1699999999000", true},
+		{"1699999990000", false},
+		{"soon", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if len(tt.header) > 0 {
+			req.Header.Set(HeaderLastWrite, tt.header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if primary != tt.want {
+			t.Errorf("%s %q: got primary %v", HeaderLastWrite, tt.header, primary)
+		}
+	}
+}
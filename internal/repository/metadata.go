@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type Field struct {
+	Index  int
+	Name   string
+	Json   string
+	Column string
+	Key    bool
+}
+
+// Metadata describes how a model struct maps to a table, derived from its `gorm:"column:..."` and `json` tags.
+// A field tagged `version:"true"` is the optimistic locking counter; fields tagged `deleted:"at"` and `deleted:"by"` record soft deletes.
+type Metadata struct {
+	Type      reflect.Type
+	Fields    []Field
+	Keys      []Field
+	Version   *Field
+	DeletedAt *Field
+	DeletedBy *Field
+	columns   map[string]int
+	jsons     map[string]int
+}
+
+var cache sync.Map
+
+func GetMetadata(modelType reflect.Type) *Metadata {
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if m, ok := cache.Load(modelType); ok {
+		return m.(*Metadata)
+	}
+	m := buildMetadata(modelType)
+	cache.Store(modelType, m)
+	return m
+}
+
+func buildMetadata(modelType reflect.Type) *Metadata {
+	m := &Metadata{Type: modelType, columns: make(map[string]int), jsons: make(map[string]int)}
+	for i := 0; i < modelType.NumField(); i++ {
+		f := modelType.Field(i)
+		column, key := parseGorm(f.Tag.Get("gorm"))
+		if len(column) == 0 {
+			continue
+		}
+		json := strings.Split(f.Tag.Get("json"), ",")[0]
+		if len(json) == 0 || json == "-" {
+			json = f.Name
+		}
+		field := Field{Index: i, Name: f.Name, Json: json, Column: column, Key: key}
+		m.columns[strings.ToLower(column)] = len(m.Fields)
+		m.jsons[json] = len(m.Fields)
+		m.Fields = append(m.Fields, field)
+		if key {
+			m.Keys = append(m.Keys, field)
+		}
+		if f.Tag.Get("version") == "true" {
+			version := field
+			m.Version = &version
+		}
+		switch f.Tag.Get("deleted") {
+		case "at":
+			deletedAt := field
+			m.DeletedAt = &deletedAt
+		case "by":
+			deletedBy := field
+			m.DeletedBy = &deletedBy
+		}
+	}
+	return m
+}
+
+func parseGorm(tag string) (string, bool) {
+	var column string
+	key := false
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "column:") {
+			column = strings.TrimPrefix(part, "column:")
+		} else if part == "primary_key" || part == "primaryKey" {
+			key = true
+		}
+	}
+	return column, key
+}
+
+func (m *Metadata) FieldByColumn(column string) (Field, bool) {
+	i, ok := m.columns[strings.ToLower(column)]
+	if !ok {
+		return Field{}, false
+	}
+	return m.Fields[i], true
+}
+
+func (m *Metadata) FieldByJson(json string) (Field, bool) {
+	i, ok := m.jsons[json]
+	if !ok {
+		return Field{}, false
+	}
+	return m.Fields[i], true
+}
+
+func (m *Metadata) Columns() []string {
+	columns := make([]string, len(m.Fields))
+	for i, f := range m.Fields {
+		columns[i] = f.Column
+	}
+	return columns
+}
+
+func (m *Metadata) KeyColumns() []string {
+	columns := make([]string, len(m.Keys))
+	for i, f := range m.Keys {
+		columns[i] = f.Column
+	}
+	return columns
+}
+
+// JsonColumnMap maps the json name of every column field to its column, as expected by core-go/sql patch builders.
+func (m *Metadata) JsonColumnMap() map[string]string {
+	result := make(map[string]string, len(m.Fields))
+	for _, f := range m.Fields {
+		result[f.Json] = f.Column
+	}
+	return result
+}
+
+// Values returns the values of the given columns from item, dereferencing pointers so a nil pointer becomes nil.
+func (m *Metadata) Values(item reflect.Value, columns []string) []interface{} {
+	item = reflect.Indirect(item)
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		f, ok := m.FieldByColumn(column)
+		if !ok {
+			continue
+		}
+		v := item.Field(f.Index)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		values[i] = v.Interface()
+	}
+	return values
+}
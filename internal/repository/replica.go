@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Replicas picks the pool, and its statement cache if any, that serves a read-only call.
+type Replicas interface {
+	Reader(ctx context.Context) (*sql.DB, *Statements)
+}
+
+type readOnlyKey struct{}
+
+// ReadOnly returns a context whose repository reads may be served by a replica. Calls inside a transaction
+// always run on the primary.
+func ReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+func IsReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
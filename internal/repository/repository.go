@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/dialect"
+)
+
+// Repository implements the common CRUD statements for one table, with columns, keys and scan targets taken from the model's tags.
+type Repository struct {
+	DB         *sql.DB
+	Table      string
+	Metadata   *Metadata
+	Dialect    *dialect.Dialect
+	BuildParam func(int) string
+	SoftDelete bool
+	Statements *Statements
+	Replicas   Replicas
+}
+
+func NewRepository(db *sql.DB, table string, modelType reflect.Type) *Repository {
+	d := dialect.Of(db)
+	return &Repository{DB: db, Table: table, Metadata: GetMetadata(modelType), Dialect: d, BuildParam: d.Param}
+}
+
+func (r *Repository) SelectColumns() string {
+	return strings.Join(r.Metadata.Columns(), ", ")
+}
+
+// All loads every row into results, which must be a pointer to a slice of the model.
+func (r *Repository) All(ctx context.Context, results interface{}) error {
+	query := fmt.Sprintf("select %s from %s%s", r.SelectColumns(), r.Table, where(r.Scope(false)))
+	return r.Query(ctx, results, query)
+}
+
+// Load scans the row with the given id into result and reports whether it was found.
+// For composite keys, id must be a []interface{} in key order.
+func (r *Repository) Load(ctx context.Context, id interface{}, result interface{}) (bool, error) {
+	keys, params, err := r.whereKeys(id, 1)
+	if err != nil {
+		return false, err
+	}
+	query := fmt.Sprintf("select %s from %s%s", r.SelectColumns(), r.Table, where(keys, r.Scope(false)))
+	return r.load(ctx, result, query, params...)
+}
+
+// LoadForUpdate is Load including soft deleted rows, locking the row until the context's transaction ends.
+func (r *Repository) LoadForUpdate(ctx context.Context, id interface{}, result interface{}) (bool, error) {
+	keys, params, err := r.whereKeys(id, 1)
+	if err != nil {
+		return false, err
+	}
+	query := fmt.Sprintf("select %s from %s%s%s", r.SelectColumns(), r.Dialect.From(r.Table, true), where(keys), r.Dialect.ForUpdate())
+	return r.load(ctx, result, query, params...)
+}
+
+func (r *Repository) load(ctx context.Context, result interface{}, query string, params ...interface{}) (bool, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, params...)
+	if err != nil {
+		return false, apperror.FromDB(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+	if err = rows.Scan(r.scanTargets(reflect.Indirect(reflect.ValueOf(result)), columns)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Repository) Insert(ctx context.Context, model interface{}) (int64, error) {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	if r.Metadata.Version != nil {
+		v.Field(r.Metadata.Version.Index).SetInt(1)
+	}
+	var columns, placeholders []string
+	var params []interface{}
+	for _, f := range r.Metadata.Fields {
+		if r.isDeletion(f) {
+			continue
+		}
+		params = append(params, v.Field(f.Index).Interface())
+		columns = append(columns, f.Column)
+		placeholders = append(placeholders, r.BuildParam(len(params)))
+	}
+	query := fmt.Sprintf("insert into %s (%s) values (%s)", r.Table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	return r.Exec(ctx, query, params...)
+}
+
+func (r *Repository) Update(ctx context.Context, model interface{}) (int64, error) {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	var sets []string
+	var params []interface{}
+	for _, f := range r.Metadata.Fields {
+		if f.Key || r.isManaged(f) {
+			continue
+		}
+		params = append(params, v.Field(f.Index).Interface())
+		sets = append(sets, fmt.Sprintf("%s = %s", f.Column, r.BuildParam(len(params))))
+	}
+	keys := make([]interface{}, len(r.Metadata.Keys))
+	for i, f := range r.Metadata.Keys {
+		keys[i] = v.Field(f.Index).Interface()
+	}
+	affected, err := r.update(ctx, sets, params, keys, r.Scope(false))
+	if err == nil && affected > 0 {
+		if versions := ExpectedVersions(ctx); r.Metadata.Version != nil && len(versions) == 1 {
+			v.Field(r.Metadata.Version.Index).SetInt(versions[0] + 1)
+		}
+	}
+	return affected, err
+}
+
+// Patch updates only the columns present in model, which is keyed by json name and must contain the primary key.
+func (r *Repository) Patch(ctx context.Context, model map[string]interface{}) (int64, error) {
+	var sets []string
+	var params []interface{}
+	for _, f := range r.Metadata.Fields {
+		if f.Key || r.isManaged(f) {
+			continue
+		}
+		if value, ok := model[f.Json]; ok {
+			params = append(params, value)
+			sets = append(sets, fmt.Sprintf("%s = %s", f.Column, r.BuildParam(len(params))))
+		}
+	}
+	keys := make([]interface{}, len(r.Metadata.Keys))
+	for i, f := range r.Metadata.Keys {
+		value, ok := model[f.Json]
+		if !ok {
+			return -1, apperror.Newf(apperror.BadRequest, "missing key %s", f.Json)
+		}
+		keys[i] = value
+	}
+	if len(sets) == 0 {
+		if _, err := r.current(ctx, keys, r.Scope(false)); err != nil {
+			return -1, err
+		}
+		return 0, nil
+	}
+	return r.update(ctx, sets, params, keys, r.Scope(false))
+}
+
+// update applies sets to the row with the given id in scope, bumping the version and honouring IfMatch.
+func (r *Repository) update(ctx context.Context, sets []string, params []interface{}, id interface{}, scope string) (int64, error) {
+	if r.Metadata.Version != nil {
+		column := r.Metadata.Version.Column
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", column, column))
+	}
+	keys, keyParams, err := r.whereKeys(id, len(params)+1)
+	if err != nil {
+		return -1, err
+	}
+	params = append(params, keyParams...)
+	version, versionParams := r.versionCondition(ctx, len(params)+1)
+	query := fmt.Sprintf("update %s set %s%s", r.Table, strings.Join(sets, ", "), where(keys, scope, version))
+	affected, err := r.Exec(ctx, query, append(params, versionParams...)...)
+	if err != nil {
+		return affected, err
+	}
+	return r.checkVersion(ctx, id, scope, affected)
+}
+
+// Delete moves the row to the trash when soft delete is on, and removes it otherwise.
+func (r *Repository) Delete(ctx context.Context, id interface{}) (int64, error) {
+	if r.softDelete() {
+		return r.trash(ctx, id)
+	}
+	keys, params, err := r.whereKeys(id, 1)
+	if err != nil {
+		return -1, err
+	}
+	version, versionParams := r.versionCondition(ctx, len(params)+1)
+	query := fmt.Sprintf("delete from %s%s", r.Table, where(keys, version))
+	affected, err := r.Exec(ctx, query, append(params, versionParams...)...)
+	if err != nil {
+		return affected, err
+	}
+	return r.checkVersion(ctx, id, "", affected)
+}
+
+// Query runs a select and appends each row to results, a pointer to a slice of the model, matching result columns to fields by name.
+func (r *Repository) Query(ctx context.Context, results interface{}, query string, params ...interface{}) error {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, params...)
+	if err != nil {
+		return apperror.FromDB(err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	slice := reflect.ValueOf(results).Elem()
+	for rows.Next() {
+		item := reflect.New(r.Metadata.Type).Elem()
+		if err = rows.Scan(r.scanTargets(item, columns)...); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+	return rows.Err()
+}
+
+func (r *Repository) Count(ctx context.Context, query string, params ...interface{}) (int64, error) {
+	var total int64
+	err := r.executor(ctx).QueryRowContext(ctx, query, params...).Scan(&total)
+	return total, apperror.FromDB(err)
+}
+
+// isManaged reports whether the repository, not the client, writes the column.
+func (r *Repository) isManaged(f Field) bool {
+	return (r.Metadata.Version != nil && r.Metadata.Version.Index == f.Index) || r.isDeletion(f)
+}
+
+func (r *Repository) isDeletion(f Field) bool {
+	return (r.Metadata.DeletedAt != nil && r.Metadata.DeletedAt.Index == f.Index) ||
+		(r.Metadata.DeletedBy != nil && r.Metadata.DeletedBy.Index == f.Index)
+}
+
+func (r *Repository) scanTargets(item reflect.Value, columns []string) []interface{} {
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if f, ok := r.Metadata.FieldByColumn(column); ok {
+			targets[i] = item.Field(f.Index).Addr().Interface()
+		} else {
+			targets[i] = new(interface{})
+		}
+	}
+	return targets
+}
+
+func (r *Repository) whereKeys(id interface{}, start int) (string, []interface{}, error) {
+	keys := r.Metadata.Keys
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("table %s has no primary key", r.Table)
+	}
+	values, ok := id.([]interface{})
+	if !ok {
+		values = []interface{}{id}
+	}
+	if len(values) != len(keys) {
+		return "", nil, fmt.Errorf("table %s expects %d key values, got %d", r.Table, len(keys), len(values))
+	}
+	conditions := make([]string, len(keys))
+	for i, f := range keys {
+		conditions[i] = fmt.Sprintf("%s = %s", f.Column, r.BuildParam(start+i))
+	}
+	return strings.Join(conditions, " and "), values, nil
+}
+
+// Exec runs a statement, inside the context's transaction if it carries one, and returns the rows affected.
+func (r *Repository) Exec(ctx context.Context, query string, params ...interface{}) (int64, error) {
+	res, err := r.executor(ctx).ExecContext(ctx, query, params...)
+	if err != nil {
+		return -1, apperror.FromDB(err)
+	}
+	return res.RowsAffected()
+}
+
+// Stream runs a select and passes each row to f as a new pointer to the model, so the result set is never held in memory.
+func (r *Repository) Stream(ctx context.Context, f func(item interface{}) error, query string, params ...interface{}) error {
+	rows, err := r.executor(ctx).QueryContext(ctx, query, params...)
+	if err != nil {
+		return apperror.FromDB(err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		item := reflect.New(r.Metadata.Type)
+		if err = rows.Scan(r.scanTargets(item.Elem(), columns)...); err != nil {
+			return err
+		}
+		if err = f(item.Interface()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Key returns the primary key of model as text, joining composite keys with ",".
+func (r *Repository) Key(model interface{}) string {
+	values := r.Metadata.Values(reflect.ValueOf(model), r.Metadata.KeyColumns())
+	keys := make([]string, len(values))
+	for i, v := range values {
+		keys[i] = fmt.Sprint(v)
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,233 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"go-service/internal/apperror"
+	"go-service/internal/audit"
+	"go-service/internal/migration"
+	"go-service/internal/model"
+	. "go-service/internal/repository"
+)
+
+// open returns the users repository of a new SQLite database migrated with the shipped scripts.
+func open(t *testing.T) *Repository {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := migration.NewMigrator(db, migration.Config{Dir: "../../migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewRepository(db, "users", reflect.TypeOf(model.User{}))
+}
+
+func load(t *testing.T, r *Repository, id string) (model.User, bool) {
+	var user model.User
+	ok, err := r.Load(context.Background(), id, &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, ok
+}
+
+// expect fails unless err carries code, or is nil when code is empty.
+func expect(t *testing.T, err error, code apperror.Code) {
+	t.Helper()
+	if len(code) == 0 {
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		return
+	}
+	if !apperror.Is(err, code) {
+		t.Fatalf("got error %v, want %s", err, code)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	ctx := context.Background()
+	r := open(t)
+	user, _ := load(t, r, "ironman")
+	if user.Version != 1 {
+		t.Fatalf("got version %d", user.Version)
+	}
+	tests := []struct {
+		name     string
+		write    func() (int64, error)
+		code     apperror.Code
+		affected int64
+		version  int64
+	}{
+		{"update bumps the version", func() (int64, error) {
+			user.Email = "tony@stark.com"
+			return r.Update(ctx, &user)
+		}, "", 1, 2},
+		{"update at a stale version", func() (int64, error) { return r.Update(IfMatch(ctx, 1), &user) }, apperror.PreconditionFailed, -1, 2},
+		{"update at the current version", func() (int64, error) { return r.Update(IfMatch(ctx, 2), &user) }, "", 1, 3},
+		{"patch at one of several versions", func() (int64, error) {
+			return r.Patch(IfMatch(ctx, 1, 3), map[string]interface{}{"id": "ironman", "phone": "0123456789"})
+		}, "", 1, 4},
+		{"patch at a stale version", func() (int64, error) {
+			return r.Patch(IfMatch(ctx, 3), map[string]interface{}{"id": "ironman", "phone": "0123456789"})
+		}, apperror.PreconditionFailed, -1, 4},
+		{"patch without fields", func() (int64, error) { return r.Patch(ctx, map[string]interface{}{"id": "ironman"}) }, "", 0, 4},
+		{"patch without a key", func() (int64, error) { return r.Patch(ctx, map[string]interface{}{"phone": "1"}) }, apperror.BadRequest, -1, 4},
+		{"delete at a stale version", func() (int64, error) { return r.Delete(IfMatch(ctx, 3), "ironman") }, apperror.PreconditionFailed, -1, 4},
+		{"update a missing row", func() (int64, error) { return r.Update(ctx, &model.User{Id: "hulk"}) }, apperror.NotFound, -1, 4},
+		{"update a missing row at a version", func() (int64, error) { return r.Update(IfMatch(ctx, 1), &model.User{Id: "hulk"}) }, apperror.NotFound, -1, 4},
+		{"patch a missing row without fields", func() (int64, error) { return r.Patch(ctx, map[string]interface{}{"id": "hulk"}) }, apperror.NotFound, -1, 4},
+		{"delete a missing row", func() (int64, error) { return r.Delete(ctx, "hulk") }, apperror.NotFound, -1, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affected, err := tt.write()
+			expect(t, err, tt.code)
+			if affected != tt.affected {
+				t.Fatalf("got %d rows affected, want %d", affected, tt.affected)
+			}
+			if current, _ := load(t, r, "ironman"); current.Version != tt.version {
+				t.Fatalf("got version %d, want %d", current.Version, tt.version)
+			}
+		})
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := WithActor(context.Background(), "admin")
+	r := open(t)
+	r.SoftDelete = true
+
+	affected, err := r.Delete(ctx, "spiderman")
+	expect(t, err, "")
+	if affected != 1 {
+		t.Fatalf("got %d rows affected", affected)
+	}
+	if _, ok := load(t, r, "spiderman"); ok {
+		t.Fatal("a deleted user was loaded")
+	}
+	var deleted []model.User
+	if err = r.Query(ctx, &deleted, "select * from users where "+r.Scope(true)); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].Id != "spiderman" || deleted[0].DeletedAt == nil || deleted[0].DeletedBy == nil || *deleted[0].DeletedBy != "admin" {
+		t.Fatalf("got trash %+v", deleted)
+	}
+	var live []model.User
+	if err = r.All(ctx, &live); err != nil {
+		t.Fatal(err)
+	}
+	if len(live) != 2 {
+		t.Fatalf("got %d live users", len(live))
+	}
+
+	_, err = r.Delete(ctx, "spiderman")
+	expect(t, err, apperror.NotFound)
+	_, err = r.Update(ctx, &deleted[0])
+	expect(t, err, apperror.NotFound)
+	_, err = r.Restore(ctx, "ironman")
+	expect(t, err, apperror.NotFound)
+
+	_, err = r.Restore(IfMatch(ctx, 1), "spiderman")
+	expect(t, err, apperror.PreconditionFailed)
+	_, err = r.Restore(IfMatch(ctx, 2), "spiderman")
+	expect(t, err, "")
+	user, ok := load(t, r, "spiderman")
+	if !ok || user.DeletedAt != nil || user.DeletedBy != nil || user.Version != 3 {
+		t.Fatalf("got restored user %+v", user)
+	}
+	_, err = r.Restore(ctx, "spiderman")
+	expect(t, err, apperror.NotFound)
+
+	if _, err = r.Delete(ctx, "wolverine"); err != nil {
+		t.Fatal(err)
+	}
+	if affected, err = r.Purge(ctx, time.Now().Add(-time.Hour), 0); err != nil || affected != 0 {
+		t.Fatalf("purged %d rows deleted within the hour: %v", affected, err)
+	}
+	if affected, err = r.Purge(ctx, time.Now().Add(time.Hour), 10); err != nil || affected != 1 {
+		t.Fatalf("purged %d rows: %v", affected, err)
+	}
+	if n, err := r.Count(ctx, "select count(*) from users"); err != nil || n != 2 {
+		t.Fatalf("got %d users left: %v", n, err)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	ctx := WithActor(context.Background(), "admin")
+	r := open(t)
+	logs := NewRepository(r.DB, "audit_logs", reflect.TypeOf(model.AuditLog{}))
+	auditor := audit.NewAuditor(logs)
+
+	user, _ := load(t, r, "ironman")
+	user.Email = "tony@stark.com"
+	if _, err := auditor.Track(ctx, r, audit.Update, user.Id, func(ctx context.Context) (int64, error) { return r.Update(ctx, &user) }); err != nil {
+		t.Fatal(err)
+	}
+	_, err := auditor.Track(ctx, r, audit.Update, user.Id, func(ctx context.Context) (int64, error) { return r.Update(IfMatch(ctx, 1), &user) })
+	expect(t, err, apperror.PreconditionFailed)
+	_, err = auditor.Track(ctx, r, audit.Delete, "hulk", func(ctx context.Context) (int64, error) { return r.Delete(ctx, "hulk") })
+	expect(t, err, apperror.NotFound)
+	// A failed write is rolled back together with its record.
+	_, err = auditor.Track(ctx, r, audit.Patch, user.Id, func(ctx context.Context) (int64, error) {
+		if _, err := r.Patch(ctx, map[string]interface{}{"id": "ironman", "phone": "1"}); err != nil {
+			return -1, err
+		}
+		return -1, apperror.New(apperror.Internal, "failed after the write")
+	})
+	expect(t, err, apperror.Internal)
+
+	var records []model.AuditLog
+	if err = logs.All(ctx, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d audit records", len(records))
+	}
+	record := records[0]
+	if record.Entity != "users" || record.EntityId != "ironman" || record.Operation != audit.Update || record.Actor == nil || *record.Actor != "admin" {
+		t.Fatalf("got audit record %+v", record)
+	}
+	var changes map[string]audit.Change
+	if err = json.Unmarshal(record.Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]audit.Change{
+		"email":   {Old: "tony.stark@gmail.com", New: "tony@stark.com"},
+		"version": {Old: float64(1), New: float64(2)},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got changes %v, want %v", changes, want)
+	}
+	if current, _ := load(t, r, "ironman"); current.Phone != "0987654321" {
+		t.Fatalf("got phone %s after the rollback", current.Phone)
+	}
+}
+
+func TestUniqueUsername(t *testing.T) {
+	ctx := context.Background()
+	r := open(t)
+	r.SoftDelete = true
+	_, err := r.Insert(ctx, &model.User{Id: "peter", Username: "peter.parker"})
+	expect(t, err, apperror.Duplicate)
+	if _, err = r.Delete(ctx, "spiderman"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Insert(ctx, &model.User{Id: "peter", Username: "peter.parker"})
+	expect(t, err, "")
+	_, err = r.Restore(ctx, "spiderman")
+	expect(t, err, apperror.Duplicate)
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+const DefaultStatementCacheSize = 256
+
+type StatementConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Size    int  `mapstructure:"size"`
+}
+
+type StatementStats struct {
+	Size       int
+	Hits       int64
+	Misses     int64
+	Reprepares int64
+	Evictions  int64
+}
+
+// Statements is the registry of prepared statements shared by every repository of one pool, keyed by their SQL.
+// database/sql prepares a statement again on each connection it runs on, so a statement is prepared once per
+// connection. The least recently used statements are closed when the registry is full.
+type Statements struct {
+	db     *sql.DB
+	size   int
+	mu     sync.Mutex
+	items  map[string]*list.Element
+	order  *list.List
+	stats  StatementStats
+	closed bool
+}
+
+type entry struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+var errClosed = errors.New("statement cache is closed")
+
+func NewStatements(db *sql.DB, c StatementConfig) *Statements {
+	size := c.Size
+	if size <= 0 {
+		size = DefaultStatementCacheSize
+	}
+	return &Statements{db: db, size: size, items: make(map[string]*list.Element), order: list.New()}
+}
+
+// Prepare returns the cached statement for query, preparing it on a miss.
+func (s *Statements) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, errClosed
+	}
+	if e, ok := s.items[query]; ok {
+		s.order.MoveToFront(e)
+		s.stats.Hits++
+		s.mu.Unlock()
+		return e.Value.(*entry).stmt, nil
+	}
+	s.stats.Misses++
+	s.mu.Unlock()
+
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		stmt.Close()
+		return nil, errClosed
+	}
+	// Another request may have prepared the same query meanwhile; keep the first one.
+	if e, ok := s.items[query]; ok {
+		stmt.Close()
+		return e.Value.(*entry).stmt, nil
+	}
+	s.items[query] = s.order.PushFront(&entry{query: query, stmt: stmt})
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+		s.stats.Evictions++
+	}
+	return stmt, nil
+}
+
+// Invalidate drops stmt, the statement cached for query, so the next call prepares it again.
+func (s *Statements) Invalidate(query string, stmt *sql.Stmt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[query]; ok && e.Value.(*entry).stmt == stmt {
+		s.remove(e)
+		s.stats.Reprepares++
+	}
+}
+
+// remove closes the statement of e. Rows still open on it keep it alive until they are closed.
+func (s *Statements) remove(e *list.Element) {
+	item := s.order.Remove(e).(*entry)
+	delete(s.items, item.query)
+	item.stmt.Close()
+}
+
+// Close closes every statement; later calls run their queries without preparing them.
+func (s *Statements) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for s.order.Len() > 0 {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+func (s *Statements) Stats() StatementStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Size = s.order.Len()
+	return stats
+}
+
+// run passes the statement for query to f, preparing it again and retrying once when the driver reports it stale.
+// Inside a transaction, only a statement closed by eviction is retried, since a failed statement may have aborted it.
+func (s *Statements) run(ctx context.Context, query string, inTx bool, f func(stmt *sql.Stmt) error) error {
+	stmt, err := s.Prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+	err = f(stmt)
+	if err != nil && (isClosed(err) || (!inTx && invalidated(err))) {
+		s.Invalidate(query, stmt)
+		if stmt, err = s.Prepare(ctx, query); err != nil {
+			return err
+		}
+		err = f(stmt)
+	}
+	return err
+}
+
+func isClosed(err error) bool {
+	return err.Error() == "sql: statement is closed"
+}
+
+// invalidated reports whether the database dropped or outdated a prepared statement, usually after a schema change.
+func invalidated(err error) bool {
+	var my *mysql.MySQLError
+	if errors.As(err, &my) {
+		return my.Number == 1615 || my.Number == 1243
+	}
+	var pg *pq.Error
+	if errors.As(err, &pg) {
+		return pg.Code == "0A000" || pg.Code == "26000"
+	}
+	var ms mssql.Error
+	if errors.As(err, &ms) {
+		return ms.Number == 8179
+	}
+	var lite *sqlite.Error
+	if errors.As(err, &lite) {
+		return lite.Code() == 17
+	}
+	return false
+}
+
+// prepared is the Executor that runs every statement through the registry, bound to tx when there is one.
+type prepared struct {
+	statements *Statements
+	db         *sql.DB
+	tx         *sql.Tx
+}
+
+func (p *prepared) bind(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	if p.tx != nil {
+		return p.tx.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
+func (p *prepared) fallback() Executor {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.db
+}
+
+func (p *prepared) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := p.statements.run(ctx, query, p.tx != nil, func(stmt *sql.Stmt) (err error) {
+		res, err = p.bind(ctx, stmt).ExecContext(ctx, args...)
+		return err
+	})
+	if err == errClosed {
+		return p.fallback().ExecContext(ctx, query, args...)
+	}
+	return res, err
+}
+
+func (p *prepared) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := p.statements.run(ctx, query, p.tx != nil, func(stmt *sql.Stmt) (err error) {
+		rows, err = p.bind(ctx, stmt).QueryContext(ctx, args...)
+		return err
+	})
+	if err == errClosed {
+		return p.fallback().QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+// QueryRowContext cannot retry, since a *sql.Row only reports its error on Scan; it falls back to an
+// unprepared query when the statement cannot be prepared.
+func (p *prepared) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := p.statements.Prepare(ctx, query)
+	if err != nil {
+		return p.fallback().QueryRowContext(ctx, query, args...)
+	}
+	return p.bind(ctx, stmt).QueryRowContext(ctx, args...)
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-service/internal/dialect"
+)
+
+type timeoutKey struct{}
+
+// WithStatementTimeout returns a context whose selects ask the database to stop after timeout, where the dialect
+// supports it. Statements are cached by their text, so timeout should be a configured value rather than the time
+// left before a deadline.
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+func StatementTimeout(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	return timeout, ok && timeout > 0
+}
+
+// limited is the Executor adding the statement timeout of the context to each query.
+type limited struct {
+	next    Executor
+	dialect *dialect.Dialect
+	timeout time.Duration
+}
+
+func (l *limited) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return l.next.ExecContext(ctx, query, args...)
+}
+
+func (l *limited) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return l.next.QueryContext(ctx, l.dialect.Limit(query, l.timeout), args...)
+}
+
+func (l *limited) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return l.next.QueryRowContext(ctx, l.dialect.Limit(query, l.timeout), args...)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-service/internal/apperror"
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose soft deletes are recorded as done by actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok && len(actor) > 0
+}
+
+func (r *Repository) softDelete() bool {
+	return r.SoftDelete && r.Metadata.DeletedAt != nil
+}
+
+// Scope returns the condition selecting live rows, or deleted ones when deleted is true.
+// It is empty when soft delete is off for this table.
+func (r *Repository) Scope(deleted bool) string {
+	if !r.softDelete() {
+		return ""
+	}
+	if deleted {
+		return r.Metadata.DeletedAt.Column + " is not null"
+	}
+	return r.Metadata.DeletedAt.Column + " is null"
+}
+
+func And(conditions ...string) string {
+	var parts []string
+	for _, c := range conditions {
+		if len(c) > 0 {
+			parts = append(parts, c)
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+func where(conditions ...string) string {
+	if s := And(conditions...); len(s) > 0 {
+		return " where " + s
+	}
+	return ""
+}
+
+func (r *Repository) trash(ctx context.Context, id interface{}) (int64, error) {
+	var actor interface{}
+	if s, ok := ActorFromContext(ctx); ok {
+		actor = s
+	}
+	sets := []string{fmt.Sprintf("%s = %s", r.Metadata.DeletedAt.Column, r.BuildParam(1))}
+	params := []interface{}{time.Now()}
+	if r.Metadata.DeletedBy != nil {
+		params = append(params, actor)
+		sets = append(sets, fmt.Sprintf("%s = %s", r.Metadata.DeletedBy.Column, r.BuildParam(len(params))))
+	}
+	return r.update(ctx, sets, params, id, r.Scope(false))
+}
+
+// Restore brings a soft deleted row back, returning NotFound when no deleted row has the given id.
+func (r *Repository) Restore(ctx context.Context, id interface{}) (int64, error) {
+	if !r.softDelete() {
+		return -1, apperror.Newf(apperror.NotFound, "soft delete is disabled for %s", r.Table)
+	}
+	sets := []string{r.Metadata.DeletedAt.Column + " = null"}
+	if r.Metadata.DeletedBy != nil {
+		sets = append(sets, r.Metadata.DeletedBy.Column+" = null")
+	}
+	return r.update(ctx, sets, nil, id, r.Scope(true))
+}
+
+// Purge hard deletes at most limit rows soft deleted before the given time; limit <= 0 means no limit.
+func (r *Repository) Purge(ctx context.Context, before time.Time, limit int) (int64, error) {
+	if !r.softDelete() {
+		return 0, nil
+	}
+	condition := fmt.Sprintf("%s < %s", r.Metadata.DeletedAt.Column, r.BuildParam(1))
+	query := fmt.Sprintf("delete from %s where %s", r.Table, condition)
+	if limit > 0 {
+		query = r.Dialect.DeleteLimit(r.Table, r.Metadata.KeyColumns(), condition, limit)
+	}
+	return r.Exec(ctx, query, before)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// WithTx returns a context whose repository calls run inside tx.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// InTx runs f in a transaction carried by its context, committing when f succeeds and rolling back otherwise.
+// When ctx already carries a transaction, f joins it and the outer caller decides the outcome.
+func InTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, f func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return f(ctx)
+	}
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err = f(WithTx(ctx, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) executor(ctx context.Context) Executor {
+	e := r.pool(ctx)
+	if timeout, ok := StatementTimeout(ctx); ok {
+		return &limited{next: e, dialect: r.Dialect, timeout: timeout}
+	}
+	return e
+}
+
+// pool returns the transaction of ctx, or the pool serving the call, behind the statement cache if there is one.
+func (r *Repository) pool(ctx context.Context) Executor {
+	tx, ok := TxFromContext(ctx)
+	db, statements := r.DB, r.Statements
+	if !ok && r.Replicas != nil && IsReadOnly(ctx) {
+		db, statements = r.Replicas.Reader(ctx)
+	}
+	if statements != nil {
+		return &prepared{statements: statements, db: db, tx: tx}
+	}
+	if ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go-service/internal/apperror"
+)
+
+type versionKey struct{}
+
+// IfMatch returns a context whose updates, patches and deletes only apply to a row at one of the given versions.
+func IfMatch(ctx context.Context, versions ...int64) context.Context {
+	return context.WithValue(ctx, versionKey{}, versions)
+}
+
+func ExpectedVersions(ctx context.Context) []int64 {
+	versions, _ := ctx.Value(versionKey{}).([]int64)
+	return versions
+}
+
+// versionCondition returns the "version in (...)" condition for the expected versions in ctx, if any.
+func (r *Repository) versionCondition(ctx context.Context, start int) (string, []interface{}) {
+	versions := ExpectedVersions(ctx)
+	if r.Metadata.Version == nil || len(versions) == 0 {
+		return "", nil
+	}
+	placeholders := make([]string, len(versions))
+	params := make([]interface{}, len(versions))
+	for i, v := range versions {
+		placeholders[i] = r.BuildParam(start + i)
+		params[i] = v
+	}
+	return fmt.Sprintf("%s in (%s)", r.Metadata.Version.Column, strings.Join(placeholders, ", ")), params
+}
+
+// checkVersion explains why a write affected no row: the row is missing from scope, or its version moved on when
+// versions were expected. Otherwise the write matched the row without changing it, and 0 is returned.
+func (r *Repository) checkVersion(ctx context.Context, id interface{}, scope string, affected int64) (int64, error) {
+	if affected > 0 {
+		return affected, nil
+	}
+	version, err := r.current(ctx, id, scope)
+	if err != nil {
+		return -1, err
+	}
+	if r.Metadata.Version == nil || len(ExpectedVersions(ctx)) == 0 {
+		return affected, nil
+	}
+	return -1, apperror.Newf(apperror.PreconditionFailed, "resource is at version %d", version)
+}
+
+// current returns the version of the row with the given id in scope, 0 when the table has no version column,
+// and NotFound when there is no such row.
+func (r *Repository) current(ctx context.Context, id interface{}, scope string) (int64, error) {
+	keys, params, err := r.whereKeys(id, 1)
+	if err != nil {
+		return -1, err
+	}
+	column := "0"
+	if r.Metadata.Version != nil {
+		column = r.Metadata.Version.Column
+	}
+	var version int64
+	query := fmt.Sprintf("select %s from %s%s", column, r.Table, where(keys, scope))
+	if err = r.executor(ctx).QueryRowContext(ctx, query, params...).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return -1, apperror.New(apperror.NotFound, "resource not found")
+		}
+		return -1, apperror.FromDB(err)
+	}
+	return version, nil
+}
@@ -0,0 +1,188 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/core-go/log"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+
+	"go-service/internal/repository"
+)
+
+const (
+	DefaultAttempts   = 3
+	DefaultBackoff    = 20
+	DefaultMaxBackoff = 500
+)
+
+// Config sets how many times an operation runs at most. Backoff and MaxBackoff are in milliseconds:
+// the n-th retry waits a random time up to Backoff * 2^(n-1), capped at MaxBackoff.
+type Config struct {
+	Attempts   int   `mapstructure:"attempts"`
+	Backoff    int64 `mapstructure:"backoff"`
+	MaxBackoff int64 `mapstructure:"max_backoff"`
+}
+
+// Reasons of a retry, as reported to Observe.
+const (
+	Deadlock      = "deadlock"
+	LockTimeout   = "lock_timeout"
+	Serialization = "serialization"
+	Busy          = "busy"
+	Connection    = "connection"
+)
+
+// Observe records one retry of the operation name for reason.
+type Observe func(name string, reason string)
+
+type Policy struct {
+	config  Config
+	observe Observe
+}
+
+func NewPolicy(c Config, observe Observe) *Policy {
+	if c.Attempts <= 0 {
+		c.Attempts = DefaultAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.MaxBackoff < c.Backoff {
+		c.MaxBackoff = c.Backoff
+	}
+	return &Policy{config: c, observe: observe}
+}
+
+// Do runs f, the operation name, and runs it again while it fails with a transient error. Lock conflicts roll
+// the statement or transaction back, so they are retried for every operation; a dropped connection may hide a
+// committed write, so it is only retried when idempotent is true. Calls joining a transaction of the context
+// are never retried: the transaction is already lost and only its owner can start it again.
+// No retry starts when its backoff would end after the context's deadline.
+func (p *Policy) Do(ctx context.Context, name string, idempotent bool, f func(ctx context.Context) error) error {
+	if _, ok := repository.TxFromContext(ctx); ok {
+		return f(ctx)
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = f(ctx); err == nil || attempt >= p.config.Attempts {
+			return err
+		}
+		reason, transient := Classify(err)
+		if !transient || (reason == Connection && !idempotent) {
+			return err
+		}
+		wait := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+		log.Warn(ctx, fmt.Sprintf("%s failed with %s, retry %d of %d in %s: %v", name, reason, attempt, p.config.Attempts-1, wait, err))
+		if p.observe != nil {
+			p.observe(name, reason)
+		}
+		if er2 := sleep(ctx, wait); er2 != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns a random wait up to the exponential bound of attempt, so that colliding requests spread out.
+func (p *Policy) backoff(attempt int) time.Duration {
+	bound := p.config.Backoff << uint(attempt-1)
+	if bound > p.config.MaxBackoff || bound <= 0 {
+		bound = p.config.MaxBackoff
+	}
+	return time.Duration(1+rand.Int63n(bound)) * time.Millisecond
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Classify reports whether err is transient and why.
+func Classify(err error) (string, bool) {
+	var my *mysql.MySQLError
+	if errors.As(err, &my) {
+		switch my.Number {
+		case 1213:
+			return Deadlock, true
+		case 1205:
+			return LockTimeout, true
+		}
+		return "", false
+	}
+	var pg *pq.Error
+	if errors.As(err, &pg) {
+		switch {
+		case pg.Code == "40P01":
+			return Deadlock, true
+		case pg.Code == "40001":
+			return Serialization, true
+		case pg.Code == "55P03":
+			return LockTimeout, true
+		case pg.Code.Class() == "08" || pg.Code == "57P01":
+			return Connection, true
+		}
+		return "", false
+	}
+	var ms mssql.Error
+	if errors.As(err, &ms) {
+		switch ms.Number {
+		case 1205:
+			return Deadlock, true
+		case 1222:
+			return LockTimeout, true
+		case 3960:
+			return Serialization, true
+		case 40197, 40501, 40613:
+			return Connection, true
+		}
+		return "", false
+	}
+	var lite *sqlite.Error
+	if errors.As(err, &lite) {
+		// The primary result code is in the low byte of the extended one.
+		switch lite.Code() & 0xff {
+		case 5, 6:
+			return Busy, true
+		}
+		return "", false
+	}
+	if connectionLost(err) {
+		return Connection, true
+	}
+	return "", false
+}
+
+func connectionLost(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection reset by peer") || strings.Contains(err.Error(), "broken pipe")
+}
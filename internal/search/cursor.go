@@ -0,0 +1,97 @@
+package search
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go-service/internal/apperror"
+)
+
+type Config struct {
+	CursorSecret string `mapstructure:"cursor_secret"`
+}
+
+// Cursor marks the boundary row of a page by the values of its sort columns.
+// Backward cursors page towards the start of the result set.
+type Cursor struct {
+	Values   []interface{} `json:"v"`
+	Sort     string        `json:"s,omitempty"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// Codec turns cursors into opaque tokens signed with HMAC-SHA256, so clients cannot forge arbitrary keyset values.
+type Codec struct {
+	secret []byte
+}
+
+// NewCodec signs with secret, or with a random key when secret is empty, in which case cursors do not survive a restart
+// and are not shared between replicas.
+func NewCodec(secret string) *Codec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &Codec{secret: key}
+}
+
+// Encode writes time values as {"t": RFC 3339 time}, so Decode returns them as time.Time and the driver binds them
+// in the same format it stored them, which text comparisons on SQLite depend on.
+func (c *Codec) Encode(cursor Cursor) string {
+	values := make([]interface{}, len(cursor.Values))
+	for i, v := range cursor.Values {
+		if t, ok := v.(time.Time); ok {
+			values[i] = map[string]string{"t": t.Format(time.RFC3339Nano)}
+		} else {
+			values[i] = v
+		}
+	}
+	cursor.Values = values
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+func (c *Codec) Decode(token string) (*Cursor, error) {
+	invalid := apperror.New(apperror.BadRequest, "invalid cursor")
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return nil, invalid
+	}
+	var cursor Cursor
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err = decoder.Decode(&cursor); err != nil {
+		return nil, invalid
+	}
+	for i, v := range cursor.Values {
+		if m, ok := v.(map[string]interface{}); ok {
+			s, _ := m["t"].(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil || len(m) != 1 {
+				return nil, invalid
+			}
+			cursor.Values[i] = t
+		}
+	}
+	return &cursor, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
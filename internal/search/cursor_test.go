@@ -0,0 +1,67 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := NewCodec("secret")
+	at := time.Date(2021, 3, 4, 5, 6, 7, 8000, time.FixedZone("", 7*3600))
+	token := c.Encode(Cursor{Values: []interface{}{"tony", 42, nil, at}, Sort: "-name,id", Backward: true})
+	cursor, err := c.Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.Sort != "-name,id" || !cursor.Backward || len(cursor.Values) != 4 {
+		t.Fatalf("got cursor %+v", cursor)
+	}
+	if cursor.Values[0] != "tony" || cursor.Values[1] != json.Number("42") || cursor.Values[2] != nil {
+		t.Fatalf("got values %#v", cursor.Values)
+	}
+	if v, ok := cursor.Values[3].(time.Time); !ok || !v.Equal(at) || v.Format(time.RFC3339Nano) != at.Format(time.RFC3339Nano) {
+		t.Fatalf("got time %#v", cursor.Values[3])
+	}
+}
+
+func TestCodecDecode(t *testing.T) {
+	c := NewCodec("secret")
+	token := c.Encode(Cursor{Values: []interface{}{"tony"}})
+	parts := strings.Split(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":["root"]}`)) + "." + parts[1]
+	tests := []struct {
+		name  string
+		codec *Codec
+		token string
+		ok    bool
+	}{
+		{"valid", c, token, true},
+		{"other secret", NewCodec("other"), token, false},
+		{"forged values", c, forged, false},
+		{"no signature", c, parts[0], false},
+		{"empty signature", c, parts[0] + ".", false},
+		{"bad base64", c, "!!." + parts[1], false},
+		{"too many parts", c, token + ".x", false},
+		{"bad time", c, c.Encode(Cursor{Values: []interface{}{map[string]string{"t": "yesterday"}}}), false},
+		{"object value", c, c.Encode(Cursor{Values: []interface{}{map[string]string{"t": "2021-03-04T05:06:07Z", "x": "1"}}}), false},
+		{"empty", c, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.codec.Decode(tt.token)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v", err)
+			}
+		})
+	}
+}
+
+func TestCodecRandomSecret(t *testing.T) {
+	token := NewCodec("").Encode(Cursor{Values: []interface{}{"tony"}})
+	if _, err := NewCodec("").Decode(token); err == nil {
+		t.Fatal("a cursor signed with one random key was accepted with another")
+	}
+}
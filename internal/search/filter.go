@@ -0,0 +1,179 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+)
+
+const (
+	Equal   = "equal"
+	Prefix  = "prefix"
+	Suffix  = "suffix"
+	Contain = "contain"
+)
+
+// likeEscaper escapes with "!", which no dialect treats specially in string literals; "[" is a wildcard in SQL Server.
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`, `[`, `![`)
+
+// Where builds the condition for every field of filter whose json name is a column of the model.
+// Strings honour the `match` tag (equal, prefix, suffix, default contain), pointers compare for equality,
+// and range structs with Min/Max fields become inclusive bounds.
+func Where(filter interface{}, metadata *repository.Metadata, buildParam func(int) string) (string, []interface{}) {
+	var conditions []string
+	var params []interface{}
+	v := reflect.Indirect(reflect.ValueOf(filter))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		column, ok := metadata.FieldByJson(name)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if fv.IsZero() {
+			continue
+		}
+		col := column.Column
+		switch fv.Kind() {
+		case reflect.String:
+			s := fv.String()
+			switch sf.Tag.Get("match") {
+			case Equal:
+				params = append(params, s)
+				conditions = append(conditions, fmt.Sprintf("%s = %s", col, buildParam(len(params))))
+			case Prefix:
+				params = append(params, likeEscaper.Replace(s)+"%")
+				conditions = append(conditions, fmt.Sprintf("%s like %s escape '!'", col, buildParam(len(params))))
+			case Suffix:
+				params = append(params, "%"+likeEscaper.Replace(s))
+				conditions = append(conditions, fmt.Sprintf("%s like %s escape '!'", col, buildParam(len(params))))
+			default:
+				params = append(params, "%"+likeEscaper.Replace(s)+"%")
+				conditions = append(conditions, fmt.Sprintf("%s like %s escape '!'", col, buildParam(len(params))))
+			}
+		case reflect.Struct:
+			if min := fv.FieldByName("Min"); min.IsValid() && !min.IsZero() {
+				params = append(params, reflect.Indirect(min).Interface())
+				conditions = append(conditions, fmt.Sprintf("%s >= %s", col, buildParam(len(params))))
+			}
+			if max := fv.FieldByName("Max"); max.IsValid() && !max.IsZero() {
+				params = append(params, reflect.Indirect(max).Interface())
+				conditions = append(conditions, fmt.Sprintf("%s <= %s", col, buildParam(len(params))))
+			}
+		default:
+			params = append(params, fv.Interface())
+			conditions = append(conditions, fmt.Sprintf("%s = %s", col, buildParam(len(params))))
+		}
+	}
+	return strings.Join(conditions, " and "), params
+}
+
+// ParseSort resolves a sort expression such as "-dateOfBirth,username" (a "-" prefix means descending)
+// against the allowed json names, and appends the primary key as a tie-breaker so the order is stable.
+func ParseSort(sort string, metadata *repository.Metadata, allowed []string) ([]Order, error) {
+	var orders []Order
+	for _, term := range strings.Split(sort, ",") {
+		term = strings.TrimSpace(term)
+		if len(term) == 0 {
+			continue
+		}
+		desc := strings.HasPrefix(term, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(term, "-"), "+")
+		f, ok := metadata.FieldByJson(name)
+		if !ok || !includes(allowed, name) {
+			return nil, apperror.Newf(apperror.BadRequest, "cannot sort by %s", name)
+		}
+		if contains(orders, f.Column) {
+			return nil, apperror.Newf(apperror.BadRequest, "duplicate sort field %s", name)
+		}
+		orders = append(orders, Order{Column: f.Column, Desc: desc})
+	}
+	for _, key := range metadata.Keys {
+		if !contains(orders, key.Column) {
+			orders = append(orders, Order{Column: key.Column})
+		}
+	}
+	return orders, nil
+}
+
+// Select resolves a projection of json names against the allowed ones into a column list, always including the
+// primary key and the sort columns a cursor needs. An empty projection selects every column.
+func Select(fields []string, metadata *repository.Metadata, allowed []string, orders []Order) (string, error) {
+	if len(fields) == 0 {
+		return "*", nil
+	}
+	var columns []string
+	add := func(column string) {
+		for _, c := range columns {
+			if c == column {
+				return
+			}
+		}
+		columns = append(columns, column)
+	}
+	for _, name := range fields {
+		f, ok := metadata.FieldByJson(name)
+		if !ok || !includes(allowed, name) {
+			return "", apperror.Newf(apperror.BadRequest, "unknown field %s", name)
+		}
+		add(f.Column)
+	}
+	for _, key := range metadata.Keys {
+		add(key.Column)
+	}
+	for _, o := range orders {
+		add(o.Column)
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+func includes(names []string, name string) bool {
+	if names == nil {
+		return true
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Project keeps only the given json fields of every item in the "list" of a search result.
+func Project(result interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return result, nil
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	list, _ := m["list"].([]interface{})
+	for i, item := range list {
+		if row, ok := item.(map[string]interface{}); ok {
+			projected := make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				if v, ok := row[f]; ok {
+					projected[f] = v
+				}
+			}
+			list[i] = projected
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-service/internal/apperror"
+)
+
+type hiddenKey struct{}
+
+// WithHidden returns a context whose searches may not use the given json fields, which the caller is not allowed to read.
+func WithHidden(ctx context.Context, fields map[string]bool) context.Context {
+	return context.WithValue(ctx, hiddenKey{}, fields)
+}
+
+func Hidden(ctx context.Context) map[string]bool {
+	fields, _ := ctx.Value(hiddenKey{}).(map[string]bool)
+	return fields
+}
+
+// Check refuses a search that filters, sorts or projects on a hidden field. Masking the response is not enough:
+// cursors carry the values of the sort columns, and prefix or range filters reveal a value one guess at a time.
+func Check(ctx context.Context, filter interface{}, sortBy string, fields []string) error {
+	hidden := Hidden(ctx)
+	if len(hidden) == 0 {
+		return nil
+	}
+	found := make(map[string]bool)
+	v := reflect.Indirect(reflect.ValueOf(filter))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if hidden[name] && !v.Field(i).IsZero() {
+			found[name] = true
+		}
+	}
+	for _, term := range strings.Split(sortBy, ",") {
+		name := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(term), "-"), "+")
+		if hidden[name] {
+			found[name] = true
+		}
+	}
+	for _, name := range fields {
+		if hidden[name] {
+			found[name] = true
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	errors := make([]apperror.FieldError, 0, len(found))
+	for name := range found {
+		errors = append(errors, apperror.FieldError{Field: name, Code: "masked", Message: "you may not search by " + name})
+	}
+	sort.Slice(errors, func(i, j int) bool { return errors[i].Field < errors[j].Field })
+	return &apperror.Error{Code: apperror.Forbidden, Message: "the search uses fields you may not read", Fields: errors}
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-service/internal/apperror"
+	"go-service/internal/filter"
+)
+
+func TestCheck(t *testing.T) {
+	viewer := WithHidden(context.Background(), map[string]bool{"email": true, "phone": true, "dateOfBirth": true})
+	day := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		filter filter.UserFilter
+		sort   string
+		fields []string
+		denied []string
+	}{
+		{"nothing hidden", context.Background(), filter.UserFilter{Email: "a"}, "-email", []string{"phone"}, nil},
+		{"visible fields", viewer, filter.UserFilter{Username: "tony", Limit: 10}, "-username,id", []string{"id", "username"}, nil},
+		{"sort carried by the cursor", viewer, filter.UserFilter{Limit: 1}, "email", nil, []string{"email"}},
+		{"descending sort", viewer, filter.UserFilter{}, "username, -phone", nil, []string{"phone"}},
+		{"prefix filter", viewer, filter.UserFilter{Email: "a1@"}, "", nil, []string{"email"}},
+		{"range filter", viewer, filter.UserFilter{DateOfBirth: &filter.TimeRange{Min: &day}}, "", nil, []string{"dateOfBirth"}},
+		{"projection", viewer, filter.UserFilter{}, "", []string{"id", "phone"}, []string{"phone"}},
+		{"every use", viewer, filter.UserFilter{Email: "a", Phone: "1"}, "-dateOfBirth", []string{"email"}, []string{"dateOfBirth", "email", "phone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.ctx, tt.filter, tt.sort, tt.fields)
+			if len(tt.denied) == 0 {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				return
+			}
+			e := apperror.As(err)
+			if e == nil || e.Code != apperror.Forbidden || len(e.Fields) != len(tt.denied) {
+				t.Fatalf("got error %v", err)
+			}
+			for i, f := range e.Fields {
+				if f.Field != tt.denied[i] {
+					t.Fatalf("got fields %v, want %v", e.Fields, tt.denied)
+				}
+			}
+		})
+	}
+}
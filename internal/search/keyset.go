@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"go-service/internal/dialect"
+)
+
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+func Reverse(orders []Order) []Order {
+	reversed := make([]Order, len(orders))
+	for i, o := range orders {
+		reversed[i] = Order{Column: o.Column, Desc: !o.Desc}
+	}
+	return reversed
+}
+
+func OrderBy(orders []Order, d *dialect.Dialect) string {
+	terms := make([]string, len(orders))
+	for i, o := range orders {
+		terms[i] = d.Order(o.Column, o.Desc)
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Keyset builds the condition selecting rows strictly after values in the given order, e.g.
+// "(a > ? or (a = ? and b > ?))". NULLs sort first when ascending and last when descending, as OrderBy writes them.
+func Keyset(orders []Order, values []interface{}, buildParam func(int) string, start int) (string, []interface{}) {
+	var terms []string
+	var params []interface{}
+	i := start
+	for n := range orders {
+		o, v := orders[n], values[n]
+		if v == nil && o.Desc {
+			continue
+		}
+		var parts []string
+		for k := 0; k < n; k++ {
+			if values[k] == nil {
+				parts = append(parts, orders[k].Column+" is null")
+			} else {
+				parts = append(parts, fmt.Sprintf("%s = %s", orders[k].Column, buildParam(i)))
+				params = append(params, values[k])
+				i++
+			}
+		}
+		switch {
+		case v == nil:
+			parts = append(parts, o.Column+" is not null")
+		case o.Desc:
+			parts = append(parts, fmt.Sprintf("(%s < %s or %s is null)", o.Column, buildParam(i), o.Column))
+			params = append(params, v)
+			i++
+		default:
+			parts = append(parts, fmt.Sprintf("%s > %s", o.Column, buildParam(i)))
+			params = append(params, v)
+			i++
+		}
+		terms = append(terms, "("+strings.Join(parts, " and ")+")")
+	}
+	if len(terms) == 0 {
+		return "1 = 0", params
+	}
+	return "(" + strings.Join(terms, " or ") + ")", params
+}
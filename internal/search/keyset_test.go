@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"go-service/internal/dialect"
+)
+
+func TestKeyset(t *testing.T) {
+	param := func(i int) string { return fmt.Sprintf("$%d", i) }
+	tests := []struct {
+		name   string
+		orders []Order
+		values []interface{}
+		start  int
+		query  string
+		params []interface{}
+	}{
+		{
+			name:   "one column",
+			orders: []Order{{Column: "id"}},
+			values: []interface{}{"b"},
+			start:  1,
+			query:  "((id > $1))",
+			params: []interface{}{"b"},
+		},
+		{
+			name:   "tie-breaker",
+			orders: []Order{{Column: "name"}, {Column: "id"}},
+			values: []interface{}{"x", "b"},
+			start:  3,
+			query:  "((name > $3) or (name = $4 and id > $5))",
+			params: []interface{}{"x", "x", "b"},
+		},
+		{
+			name:   "descending includes nulls after the value",
+			orders: []Order{{Column: "name", Desc: true}, {Column: "id"}},
+			values: []interface{}{"x", "b"},
+			start:  1,
+			query:  "(((name < $1 or name is null)) or (name = $2 and id > $3))",
+			params: []interface{}{"x", "x", "b"},
+		},
+		{
+			name:   "null ascending is followed by every value",
+			orders: []Order{{Column: "name"}, {Column: "id"}},
+			values: []interface{}{nil, "b"},
+			start:  1,
+			query:  "((name is not null) or (name is null and id > $1))",
+			params: []interface{}{"b"},
+		},
+		{
+			name:   "null descending is last",
+			orders: []Order{{Column: "name", Desc: true}, {Column: "id"}},
+			values: []interface{}{nil, "b"},
+			start:  1,
+			query:  "((name is null and id > $1))",
+			params: []interface{}{"b"},
+		},
+		{
+			name:   "nothing after the last row",
+			orders: []Order{{Column: "name", Desc: true}},
+			values: []interface{}{nil},
+			start:  1,
+			query:  "1 = 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params := Keyset(tt.orders, tt.values, param, tt.start)
+			if query != tt.query {
+				t.Fatalf("got query %s, want %s", query, tt.query)
+			}
+			if len(params) != len(tt.params) || (len(params) > 0 && !reflect.DeepEqual(params, tt.params)) {
+				t.Fatalf("got params %v, want %v", params, tt.params)
+			}
+		})
+	}
+}
+
+// TestKeysetPaging walks a table with NULLs one row at a time in every order and checks that the keyset
+// condition visits the rows exactly in the order the database sorts them.
+func TestKeysetPaging(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err = db.ExecContext(ctx, "create table items (id varchar(10) primary key, name varchar(10), rank int)"); err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]interface{}{
+		{"a", "x", 1}, {"b", nil, 2}, {"c", "y", nil}, {"d", "x", nil}, {"e", nil, 1}, {"f", "y", 2}, {"g", "x", 1},
+	}
+	for _, r := range rows {
+		if _, err = db.ExecContext(ctx, "insert into items values (?, ?, ?)", r...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &dialect.Dialect{Name: dialect.SQLite}
+	param := func(int) string { return "?" }
+	for _, orders := range [][]Order{
+		{{Column: "name"}, {Column: "id"}},
+		{{Column: "name", Desc: true}, {Column: "id"}},
+		{{Column: "name"}, {Column: "rank", Desc: true}, {Column: "id"}},
+		{{Column: "rank", Desc: true}, {Column: "name", Desc: true}, {Column: "id", Desc: true}},
+	} {
+		t.Run(OrderBy(orders, d), func(t *testing.T) {
+			columns := make([]string, len(orders))
+			for i, o := range orders {
+				columns[i] = o.Column
+			}
+			query := fmt.Sprintf("select %s from items order by %s", strings.Join(columns, ", "), OrderBy(orders, d))
+			want, err := scan(ctx, db, query, len(orders))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]interface{}
+			var last []interface{}
+			for len(got) <= len(rows) {
+				query := fmt.Sprintf("select %s from items", strings.Join(columns, ", "))
+				var params []interface{}
+				if last != nil {
+					var condition string
+					condition, params = Keyset(orders, last, param, 1)
+					query += " where " + condition
+				}
+				page, err := scan(ctx, db, query+" order by "+OrderBy(orders, d)+" limit 1", len(orders), params...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, page[0])
+				last = page[0]
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func scan(ctx context.Context, db *sql.DB, query string, n int, params ...interface{}) ([][]interface{}, error) {
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, n)
+		targets := make([]interface{}, n)
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err = rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+		result = append(result, values)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-service/internal/apperror"
+	"go-service/internal/repository"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 1000
+)
+
+type Page struct {
+	Columns string
+	Where   string
+	Params  []interface{}
+	Sort    string
+	Orders  []Order
+	Cursor  string
+	Limit   int64
+}
+
+// Query loads one page of rows after (or, for backward cursors, before) the page cursor into results,
+// a pointer to a slice of the repository model, and returns the cursors of the adjacent pages.
+func (c *Codec) Query(ctx context.Context, repo *repository.Repository, results interface{}, page Page) (string, string, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	var cursor *Cursor
+	if len(page.Cursor) > 0 {
+		var err error
+		if cursor, err = c.Decode(page.Cursor); err != nil {
+			return "", "", err
+		}
+		if cursor.Sort != page.Sort || len(cursor.Values) != len(page.Orders) {
+			return "", "", apperror.New(apperror.BadRequest, "cursor does not match the requested sort")
+		}
+	}
+	backward := cursor != nil && cursor.Backward
+	orders := page.Orders
+	if backward {
+		orders = Reverse(orders)
+	}
+
+	var conditions []string
+	params := page.Params
+	if len(page.Where) > 0 {
+		conditions = append(conditions, page.Where)
+	}
+	if cursor != nil {
+		keyset, keysetParams := Keyset(orders, cursor.Values, repo.BuildParam, len(params)+1)
+		conditions = append(conditions, keyset)
+		params = append(params, keysetParams...)
+	}
+	columns := page.Columns
+	if len(columns) == 0 {
+		columns = "*"
+	}
+	query := fmt.Sprintf("select %s from %s", columns, repo.Table)
+	if len(conditions) > 0 {
+		query = query + " where " + strings.Join(conditions, " and ")
+	}
+	clause, pageParams := repo.Dialect.Page(limit+1, 0, true, len(params)+1)
+	query = query + " order by " + OrderBy(orders, repo.Dialect) + clause
+	params = append(params, pageParams...)
+	if err := repo.Query(ctx, results, query, params...); err != nil {
+		return "", "", err
+	}
+
+	rows := reflect.ValueOf(results).Elem()
+	more := int64(rows.Len()) > limit
+	if more {
+		rows.Set(rows.Slice(0, int(limit)))
+	}
+	if backward {
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			a, b := rows.Index(i).Interface(), rows.Index(j).Interface()
+			rows.Index(i).Set(reflect.ValueOf(b))
+			rows.Index(j).Set(reflect.ValueOf(a))
+		}
+	}
+	if rows.Len() == 0 {
+		return "", "", nil
+	}
+	keys := make([]string, len(page.Orders))
+	for i, o := range page.Orders {
+		keys[i] = o.Column
+	}
+	var next, prev string
+	if (!backward && more) || backward {
+		last := repo.Metadata.Values(rows.Index(rows.Len()-1), keys)
+		next = c.Encode(Cursor{Values: last, Sort: page.Sort})
+	}
+	if (backward && more) || (!backward && cursor != nil) {
+		first := repo.Metadata.Values(rows.Index(0), keys)
+		prev = c.Encode(Cursor{Values: first, Sort: page.Sort, Backward: true})
+	}
+	return next, prev, nil
+}
+
+func contains(orders []Order, column string) bool {
+	for _, o := range orders {
+		if o.Column == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-service/internal/migration"
+	"go-service/internal/model"
+	"go-service/internal/repository"
+)
+
+// TestQueryPagesByDate pages users sorted by date of birth to the end, over the seed rows, rows written by the
+// driver with equal dates, a time in another zone and NULLs, and checks every row comes exactly once in order.
+func TestQueryPagesByDate(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m, err := migration.NewMigrator(db, migration.Config{Dir: "../../migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewRepository(db, "users", reflect.TypeOf(model.User{}))
+	date := func(year int, month time.Month, day int, zone *time.Location) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, zone)
+		return &d
+	}
+	for _, u := range []model.User{
+		{Id: "a1", Username: "a1", DateOfBirth: date(1970, 1, 1, time.UTC)},
+		{Id: "a2", Username: "a2", DateOfBirth: date(1970, 1, 1, time.UTC)},
+		{Id: "a3", Username: "a3", DateOfBirth: date(1963, 3, 25, time.UTC)},
+		{Id: "a4", Username: "a4", DateOfBirth: date(1980, 6, 1, time.FixedZone("", 2*3600))},
+		{Id: "a5", Username: "a5"},
+		{Id: "a6", Username: "a6"},
+	} {
+		if _, err = repo.Insert(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	codec := NewCodec("secret")
+	for _, sort := range []string{"dateOfBirth", "-dateOfBirth"} {
+		t.Run(sort, func(t *testing.T) {
+			orders, err := ParseSort(sort, repo.Metadata, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var all []model.User
+			if err = repo.Query(ctx, &all, "select * from users order by "+OrderBy(orders, repo.Dialect)); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			cursor := ""
+			for len(got) <= len(all) {
+				var users []model.User
+				next, _, err := codec.Query(ctx, repo, &users, Page{Sort: sort, Orders: orders, Cursor: cursor, Limit: 2})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, u := range users {
+					got = append(got, u.Id)
+				}
+				if len(next) == 0 {
+					break
+				}
+				cursor = next
+			}
+			want := make([]string, len(all))
+			for i, u := range all {
+				want[i] = u.Id
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	. "go-service/internal/filter"
+	. "go-service/internal/model"
+	"go-service/internal/repository"
+	"go-service/internal/search"
+)
+
+type AuditService interface {
+	Search(ctx context.Context, filter AuditFilter) (*AuditResult, error)
+}
+
+type auditService struct {
+	repository *repository.Repository
+	cursor     *search.Codec
+}
+
+func NewAuditService(repository *repository.Repository, cursor *search.Codec) AuditService {
+	return &auditService{repository: repository, cursor: cursor}
+}
+
+// Search pages through the audit records matching filter, newest first.
+func (s *auditService) Search(ctx context.Context, filter AuditFilter) (*AuditResult, error) {
+	ctx = repository.ReadOnly(ctx)
+	var conditions []string
+	var params []interface{}
+	for _, c := range []struct{ column, value string }{
+		{"entity", filter.Entity},
+		{"entity_id", filter.Id},
+		{"operation", filter.Operation},
+		{"actor", filter.Actor},
+	} {
+		if len(c.value) > 0 {
+			params = append(params, c.value)
+			conditions = append(conditions, c.column+" = "+s.repository.BuildParam(len(params)))
+		}
+	}
+	page := search.Page{
+		Columns: s.repository.SelectColumns(),
+		Where:   repository.And(conditions...),
+		Params:  params,
+		Sort:    "-id",
+		Orders:  []search.Order{{Column: "id", Desc: true}},
+		Cursor:  filter.Cursor,
+		Limit:   filter.Limit,
+	}
+	var logs []AuditLog
+	next, prev, err := s.cursor.Query(ctx, s.repository, &logs, page)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditResult{List: logs, NextCursor: next, PrevCursor: prev}, nil
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-service/internal/apperror"
+	"go-service/internal/audit"
+	"go-service/internal/auth"
+	. "go-service/internal/model"
+	"go-service/internal/repository"
+)
+
+const (
+	DefaultAccessTTL  = 15 * 60
+	DefaultRefreshTTL = 14 * 24 * 60 * 60
+	DefaultLockout    = 15 * 60
+)
+
+var (
+	errInvalidLogin = apperror.New(apperror.Unauthorized, "invalid username or password")
+	errInvalidToken = apperror.New(apperror.Unauthorized, "invalid refresh token")
+	// dummyHash is verified for unknown users, so that the response time does not tell which usernames exist
+	dummyHash, _ = auth.HashPassword("dummy password")
+)
+
+type AuthService interface {
+	Login(ctx context.Context, login Login) (*Token, error)
+	Refresh(ctx context.Context, refreshToken string) (*Token, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ChangePassword(ctx context.Context, userId string, change PasswordChange) error
+}
+
+type authService struct {
+	users       *repository.Repository
+	credentials *repository.Repository
+	tokens      *repository.Repository
+	signer      *auth.Signer
+	auditor     *audit.Auditor
+	config      auth.LoginConfig
+}
+
+func NewAuthService(users, credentials, tokens *repository.Repository, signer *auth.Signer, auditor *audit.Auditor, config auth.LoginConfig) AuthService {
+	if config.AccessTTL <= 0 {
+		config.AccessTTL = DefaultAccessTTL
+	}
+	if config.RefreshTTL <= 0 {
+		config.RefreshTTL = DefaultRefreshTTL
+	}
+	if config.Lockout <= 0 {
+		config.Lockout = DefaultLockout
+	}
+	return &authService{users: users, credentials: credentials, tokens: tokens, signer: signer, auditor: auditor, config: config}
+}
+
+// Login checks the password of the user with the given username. After MaxAttempts failures in a row
+// the account is locked for Lockout seconds, during which even the right password is refused.
+func (s *authService) Login(ctx context.Context, login Login) (*Token, error) {
+	if s.signer == nil {
+		return nil, apperror.New(apperror.NotFound, "login is not configured")
+	}
+	var users []User
+	query := fmt.Sprintf("select %s from %s where %s", s.users.SelectColumns(), s.users.Table,
+		repository.And("username = "+s.users.BuildParam(1), s.users.Scope(false)))
+	if err := s.users.Query(ctx, &users, query, login.Username); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		auth.VerifyPassword(login.Password, dummyHash)
+		return nil, errInvalidLogin
+	}
+	// live usernames are unique since migrations 0007 and 0009; older data could still hold duplicates, and picking one
+	// of them would let whoever created the other sign in as its owner
+	if len(users) > 1 {
+		return nil, apperror.Wrap(apperror.Internal, "internal server error", fmt.Errorf("username %s matches %d users", login.Username, len(users)))
+	}
+	var token *Token
+	var failure error
+	err := repository.InTx(ctx, s.credentials.DB, nil, func(ctx context.Context) error {
+		var c Credential
+		ok, err := s.credentials.LoadForUpdate(ctx, users[0].Id, &c)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			auth.VerifyPassword(login.Password, dummyHash)
+			failure = errInvalidLogin
+			return nil
+		}
+		now := time.Now()
+		if c.LockedUntil != nil && c.LockedUntil.After(now) {
+			failure = apperror.New(apperror.Locked, "account is locked after too many failed logins, try again later")
+			return nil
+		}
+		valid, err := auth.VerifyPassword(login.Password, c.PasswordHash)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			failure = errInvalidLogin
+			c.FailedAttempts++
+			if s.config.MaxAttempts > 0 && c.FailedAttempts >= s.config.MaxAttempts {
+				until := now.Add(time.Duration(s.config.Lockout) * time.Second)
+				c.LockedUntil = &until
+				c.FailedAttempts = 0
+			}
+			_, err = s.credentials.Update(ctx, &c)
+			return err
+		}
+		if c.FailedAttempts > 0 || c.LockedUntil != nil {
+			c.FailedAttempts = 0
+			c.LockedUntil = nil
+			if _, err = s.credentials.Update(ctx, &c); err != nil {
+				return err
+			}
+		}
+		family, err := randomHex()
+		if err != nil {
+			return err
+		}
+		token, _, err = s.issue(ctx, c, family)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, failure
+}
+
+// Refresh rotates a refresh token: the presented token is revoked and replaced by a new one of the same family.
+// Presenting a revoked token means it leaked, so its whole family is revoked.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if s.signer == nil {
+		return nil, apperror.New(apperror.NotFound, "login is not configured")
+	}
+	var token *Token
+	var failure error
+	err := repository.InTx(ctx, s.tokens.DB, nil, func(ctx context.Context) error {
+		var t RefreshToken
+		ok, err := s.tokens.LoadForUpdate(ctx, hashToken(refreshToken), &t)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		switch {
+		case !ok, now.After(t.ExpiresAt):
+			failure = errInvalidToken
+			return nil
+		case t.RevokedAt != nil:
+			failure = errInvalidToken
+			return s.revoke(ctx, "family", t.Family)
+		}
+		var user User
+		if ok, err = s.users.Load(ctx, t.UserId, &user); err != nil || !ok {
+			failure = errInvalidToken
+			return err
+		}
+		var c Credential
+		if ok, err = s.credentials.Load(ctx, t.UserId, &c); err != nil || !ok {
+			failure = errInvalidToken
+			return err
+		}
+		var id string
+		if token, id, err = s.issue(ctx, c, t.Family); err != nil {
+			return err
+		}
+		t.RevokedAt = &now
+		t.ReplacedBy = &id
+		_, err = s.tokens.Update(ctx, &t)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, failure
+}
+
+// Logout revokes the family of the refresh token. Unknown tokens are ignored.
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	var t RefreshToken
+	ok, err := s.tokens.Load(ctx, hashToken(refreshToken), &t)
+	if err != nil || !ok {
+		return err
+	}
+	return s.revoke(ctx, "family", t.Family)
+}
+
+// ChangePassword lets users change their own password by giving the current one,
+// and callers with one of the reset roles set anybody's password without it. It signs the user out everywhere.
+// Anonymous callers are refused, even on routes exempt from authentication.
+func (s *authService) ChangePassword(ctx context.Context, userId string, change PasswordChange) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return apperror.New(apperror.Unauthorized, "sign in to change a password")
+	}
+	reset := auth.HasRole(p, s.config.ResetRoles)
+	if !reset && p.Subject != userId {
+		return apperror.New(apperror.Forbidden, "you may only change your own password")
+	}
+	var user User
+	ok, err := s.users.Load(ctx, userId, &user)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperror.Newf(apperror.NotFound, "user %s not found", userId)
+	}
+	hash, err := auth.HashPassword(change.NewPassword)
+	if err != nil {
+		return err
+	}
+	return repository.InTx(ctx, s.credentials.DB, nil, func(ctx context.Context) error {
+		var c Credential
+		exists, err := s.credentials.LoadForUpdate(ctx, userId, &c)
+		if err != nil {
+			return err
+		}
+		if !reset {
+			valid := false
+			if exists && len(change.CurrentPassword) > 0 {
+				if valid, err = auth.VerifyPassword(change.CurrentPassword, c.PasswordHash); err != nil {
+					return err
+				}
+			}
+			if !valid {
+				return apperror.NewValidation([]apperror.FieldError{{Field: "currentPassword", Code: "password", Message: "current password is incorrect"}})
+			}
+		}
+		c.UserId = userId
+		c.PasswordHash = hash
+		c.FailedAttempts = 0
+		c.LockedUntil = nil
+		c.UpdatedAt = time.Now()
+		if exists {
+			_, err = s.credentials.Update(ctx, &c)
+		} else {
+			_, err = s.credentials.Insert(ctx, &c)
+		}
+		if err != nil {
+			return err
+		}
+		if err = s.revoke(ctx, "user_id", userId); err != nil {
+			return err
+		}
+		return s.auditor.Log(ctx, s.users.Table, userId, audit.Password, nil, nil)
+	})
+}
+
+func (s *authService) issue(ctx context.Context, c Credential, family string) (*Token, string, error) {
+	roles := s.config.Roles
+	if c.Roles != nil {
+		roles = strings.FieldsFunc(*c.Roles, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	access, err := s.signer.Sign(c.UserId, roles, time.Duration(s.config.AccessTTL)*time.Second)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now()
+	t := RefreshToken{Id: hashToken(refresh), UserId: c.UserId, Family: family, CreatedAt: now, ExpiresAt: now.Add(time.Duration(s.config.RefreshTTL) * time.Second)}
+	if _, err = s.tokens.Insert(ctx, &t); err != nil {
+		return nil, "", err
+	}
+	return &Token{AccessToken: access, TokenType: "Bearer", ExpiresIn: s.config.AccessTTL, RefreshToken: refresh, RefreshExpiresIn: s.config.RefreshTTL}, t.Id, nil
+}
+
+func (s *authService) revoke(ctx context.Context, column string, value string) error {
+	query := fmt.Sprintf("update %s set revoked_at = %s where %s = %s and revoked_at is null", s.tokens.Table, s.tokens.BuildParam(1), column, s.tokens.BuildParam(2))
+	_, err := s.tokens.Exec(ctx, query, time.Now(), value)
+	return err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	. "go-service/internal/filter"
+	. "go-service/internal/model"
+)
+
+// Observe records the latency of the service method name, which started at start and returned err.
+type Observe func(name string, start time.Time, err error)
+
+type measuredUserService struct {
+	next    UserService
+	observe Observe
+}
+
+// MeasureUserService times every method of next under the query names "users.<Method>".
+func MeasureUserService(next UserService, observe Observe) UserService {
+	return &measuredUserService{next: next, observe: observe}
+}
+
+func (s *measuredUserService) All(ctx context.Context) ([]User, error) {
+	start := time.Now()
+	users, err := s.next.All(ctx)
+	s.observe("users.All", start, err)
+	return users, err
+}
+
+func (s *measuredUserService) Load(ctx context.Context, id string) (*User, error) {
+	start := time.Now()
+	user, err := s.next.Load(ctx, id)
+	s.observe("users.Load", start, err)
+	return user, err
+}
+
+func (s *measuredUserService) Insert(ctx context.Context, user *User) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Insert(ctx, user)
+	s.observe("users.Insert", start, err)
+	return res, err
+}
+
+func (s *measuredUserService) Update(ctx context.Context, user *User) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Update(ctx, user)
+	s.observe("users.Update", start, err)
+	return res, err
+}
+
+func (s *measuredUserService) Patch(ctx context.Context, user map[string]interface{}) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Patch(ctx, user)
+	s.observe("users.Patch", start, err)
+	return res, err
+}
+
+func (s *measuredUserService) Delete(ctx context.Context, id string) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Delete(ctx, id)
+	s.observe("users.Delete", start, err)
+	return res, err
+}
+
+func (s *measuredUserService) Search(ctx context.Context, filter UserFilter) (*Result, error) {
+	start := time.Now()
+	result, err := s.next.Search(ctx, filter)
+	s.observe("users.Search", start, err)
+	return result, err
+}
+
+func (s *measuredUserService) Trash(ctx context.Context, filter UserFilter) (*Result, error) {
+	start := time.Now()
+	result, err := s.next.Trash(ctx, filter)
+	s.observe("users.Trash", start, err)
+	return result, err
+}
+
+func (s *measuredUserService) Restore(ctx context.Context, id string) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Restore(ctx, id)
+	s.observe("users.Restore", start, err)
+	return res, err
+}
+
+type measuredMovieService struct {
+	next    MovieService
+	observe Observe
+}
+
+// MeasureMovieService times every method of next under the query names "movies.<Method>".
+func MeasureMovieService(next MovieService, observe Observe) MovieService {
+	return &measuredMovieService{next: next, observe: observe}
+}
+
+func (s *measuredMovieService) All(ctx context.Context) ([]Movie, error) {
+	start := time.Now()
+	movies, err := s.next.All(ctx)
+	s.observe("movies.All", start, err)
+	return movies, err
+}
+
+func (s *measuredMovieService) Load(ctx context.Context, id string) (*Movie, error) {
+	start := time.Now()
+	movie, err := s.next.Load(ctx, id)
+	s.observe("movies.Load", start, err)
+	return movie, err
+}
+
+func (s *measuredMovieService) Insert(ctx context.Context, movie *Movie) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Insert(ctx, movie)
+	s.observe("movies.Insert", start, err)
+	return res, err
+}
+
+func (s *measuredMovieService) Update(ctx context.Context, movie *Movie) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Update(ctx, movie)
+	s.observe("movies.Update", start, err)
+	return res, err
+}
+
+func (s *measuredMovieService) Patch(ctx context.Context, movie map[string]interface{}) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Patch(ctx, movie)
+	s.observe("movies.Patch", start, err)
+	return res, err
+}
+
+func (s *measuredMovieService) Delete(ctx context.Context, id string) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Delete(ctx, id)
+	s.observe("movies.Delete", start, err)
+	return res, err
+}
+
+func (s *measuredMovieService) Search(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	start := time.Now()
+	result, err := s.next.Search(ctx, filter)
+	s.observe("movies.Search", start, err)
+	return result, err
+}
+
+func (s *measuredMovieService) Trash(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	start := time.Now()
+	result, err := s.next.Trash(ctx, filter)
+	s.observe("movies.Trash", start, err)
+	return result, err
+}
+
+func (s *measuredMovieService) Restore(ctx context.Context, id string) (int64, error) {
+	start := time.Now()
+	res, err := s.next.Restore(ctx, id)
+	s.observe("movies.Restore", start, err)
+	return res, err
+}
+
+type measuredAuditService struct {
+	next    AuditService
+	observe Observe
+}
+
+func MeasureAuditService(next AuditService, observe Observe) AuditService {
+	return &measuredAuditService{next: next, observe: observe}
+}
+
+func (s *measuredAuditService) Search(ctx context.Context, filter AuditFilter) (*AuditResult, error) {
+	start := time.Now()
+	result, err := s.next.Search(ctx, filter)
+	s.observe("audit.Search", start, err)
+	return result, err
+}
+
+type measuredAuthService struct {
+	next    AuthService
+	observe Observe
+}
+
+func MeasureAuthService(next AuthService, observe Observe) AuthService {
+	return &measuredAuthService{next: next, observe: observe}
+}
+
+func (s *measuredAuthService) Login(ctx context.Context, login Login) (*Token, error) {
+	start := time.Now()
+	token, err := s.next.Login(ctx, login)
+	s.observe("auth.Login", start, err)
+	return token, err
+}
+
+func (s *measuredAuthService) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	start := time.Now()
+	token, err := s.next.Refresh(ctx, refreshToken)
+	s.observe("auth.Refresh", start, err)
+	return token, err
+}
+
+func (s *measuredAuthService) Logout(ctx context.Context, refreshToken string) error {
+	start := time.Now()
+	err := s.next.Logout(ctx, refreshToken)
+	s.observe("auth.Logout", start, err)
+	return err
+}
+
+func (s *measuredAuthService) ChangePassword(ctx context.Context, userId string, change PasswordChange) error {
+	start := time.Now()
+	err := s.next.ChangePassword(ctx, userId, change)
+	s.observe("auth.ChangePassword", start, err)
+	return err
+}
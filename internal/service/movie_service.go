@@ -2,13 +2,21 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	q "github.com/core-go/sql"
+	"reflect"
+
+	"go-service/internal/apperror"
+	"go-service/internal/audit"
+	"go-service/internal/dialect"
 	. "go-service/internal/filter"
 	. "go-service/internal/model"
-	"reflect"
-	"strings"
+	"go-service/internal/repository"
+	"go-service/internal/search"
+)
+
+var (
+	movieMetadata = repository.GetMetadata(reflect.TypeOf(Movie{}))
+	movieSortable = []string{"id", "name", "watched", "deletedAt"}
 )
 
 type MovieService interface {
@@ -19,174 +27,179 @@ type MovieService interface {
 	Patch(ctx context.Context, movie map[string]interface{}) (int64, error)
 	Delete(ctx context.Context, id string) (int64, error)
 	Search(ctx context.Context, filter MovieFilter) (*ResultMovie, error)
+	Trash(ctx context.Context, filter MovieFilter) (*ResultMovie, error)
+	Restore(ctx context.Context, id string) (int64, error)
 }
 
 type movieService struct {
-	DB         *sql.DB
-	BuildParam func(int) string
+	repository *repository.Repository
+	cursor     *search.Codec
+	auditor    *audit.Auditor
 }
 
-func NewMovieService(db *sql.DB) MovieService {
-	buildParam := q.GetBuild(db)
-	return &movieService{DB: db, BuildParam: buildParam}
+func NewMovieService(repository *repository.Repository, cursor *search.Codec, auditor *audit.Auditor) MovieService {
+	return &movieService{repository: repository, cursor: cursor, auditor: auditor}
 }
 
 func (m *movieService) All(ctx context.Context) ([]Movie, error) {
-	query := "select * from movies"
-	rows, err := m.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	ctx = repository.ReadOnly(ctx)
 	var movies []Movie
-	for rows.Next() {
-		var movie Movie
-		err = rows.Scan(&movie.Id, &movie.Name, &movie.Watched)
-		if err != nil {
-			return nil, err
-		}
-		movies = append(movies, movie)
-	}
-	return movies, nil
+	err := m.repository.All(ctx, &movies)
+	return movies, err
 }
 
 func (m *movieService) Load(ctx context.Context, id string) (*Movie, error) {
-	query := "select * from movies where id = ? limit 1"
-	rows, err := m.DB.QueryContext(ctx, query, id)
+	ctx = repository.ReadOnly(ctx)
+	var movie Movie
+	ok, err := m.repository.Load(ctx, id, &movie)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var movie Movie
-		err := rows.Scan(&movie.Id, &movie.Name, &movie.Watched)
-		if err != nil {
-			return nil, err
-		}
-		return &movie, nil
+	if !ok {
+		return nil, apperror.Newf(apperror.NotFound, "movie %s not found", id)
 	}
-	return nil, nil
-
+	return &movie, nil
 }
 
 func (m *movieService) Insert(ctx context.Context, movie *Movie) (int64, error) {
-	query := "insert into movies (id, name, watched) values (?,?.?)"
-	stmt, err := m.DB.Prepare(query)
-	if err != nil {
-		return -1, err
-	}
-	res, err1 := stmt.ExecContext(ctx, movie.Id, movie.Name, movie.Watched)
-	if err1 != nil {
-		return -1, err1
-	}
-	return res.RowsAffected()
+	return m.auditor.Track(ctx, m.repository, audit.Insert, movie.Id, func(ctx context.Context) (int64, error) {
+		return m.repository.Insert(ctx, movie)
+	})
 }
 
 func (m *movieService) Update(ctx context.Context, movie *Movie) (int64, error) {
-	query := "update movies set name = ?, watched = ?"
-	stmt, err := m.DB.Prepare(query)
-	if err != nil {
-		return -1, err
-	}
-	res, err1 := stmt.ExecContext(ctx, movie.Name, movie.Watched)
-	if err1 != nil {
-		return -1, err1
-	}
-	return res.RowsAffected()
+	return m.auditor.Track(ctx, m.repository, audit.Update, movie.Id, func(ctx context.Context) (int64, error) {
+		return m.repository.Update(ctx, movie)
+	})
 }
 
 func (m *movieService) Patch(ctx context.Context, movie map[string]interface{}) (int64, error) {
-	movieType := reflect.TypeOf(Movie{})
-	jsonColumnMap := q.MakeJsonColumnMap(movieType)
-	colMap := q.JSONToColumns(movie, jsonColumnMap)
-	keys, _ := q.FindPrimaryKeys(movieType)
-	query, agrs := q.BuildToPatch("movies", colMap, keys, q.BuildParam)
-	res, err := m.DB.ExecContext(ctx, query, agrs...)
-	if err != nil {
-		return -1, err
+	return m.auditor.Track(ctx, m.repository, audit.Patch, movie["id"], func(ctx context.Context) (int64, error) {
+		return m.repository.Patch(ctx, movie)
+	})
+}
+
+func (m *movieService) Delete(ctx context.Context, id string) (int64, error) {
+	return m.auditor.Track(ctx, m.repository, audit.Delete, id, func(ctx context.Context) (int64, error) {
+		return m.repository.Delete(ctx, id)
+	})
+}
+
+func (m *movieService) Restore(ctx context.Context, id string) (int64, error) {
+	return m.auditor.Track(ctx, m.repository, audit.Restore, id, func(ctx context.Context) (int64, error) {
+		return m.repository.Restore(ctx, id)
+	})
+}
+
+func (m *movieService) Search(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	return m.search(ctx, filter, m.repository.Scope(false))
+}
+
+// Trash searches the soft deleted movies with the same filters as Search.
+func (m *movieService) Trash(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	scope := m.repository.Scope(true)
+	if len(scope) == 0 {
+		return nil, apperror.New(apperror.NotFound, "soft delete is disabled for movies")
 	}
-	return res.RowsAffected()
+	return m.search(ctx, filter, scope)
 }
 
-func (m movieService) Delete(ctx context.Context, id string) (int64, error) {
-	query := "delete from movie where id = ?"
-	stmt, err := m.DB.Prepare(query)
-	if err != nil {
-		return -1, err
+func (m *movieService) search(ctx context.Context, filter MovieFilter, scope string) (*ResultMovie, error) {
+	ctx = repository.ReadOnly(ctx)
+	if err := search.Check(ctx, filter, filter.Sort, filter.Fields); err != nil {
+		return nil, err
 	}
-	res, err := stmt.ExecContext(ctx, id)
+	if len(filter.Cursor) > 0 || filter.Limit > 0 {
+		return m.searchByCursor(ctx, filter, scope)
+	}
+	var movies []Movie
+	query, params, err := BuildMovieQuery(filter, scope, m.repository.Dialect)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	return res.RowsAffected()
+	if err = m.repository.Query(ctx, &movies, query, params...); err != nil {
+		return nil, err
+	}
+	result := &ResultMovie{List: movies}
+	if !filter.SkipTotal {
+		query, params = BuildMovieCount(filter, scope, m.repository.BuildParam)
+		if result.Total, err = m.repository.Count(ctx, query, params...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
-func (m movieService) Search(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
-	query, param := BuildMovieQuery(filter, m.BuildParam)
-	rows, err := m.DB.QueryContext(ctx, query, param...)
+func (m *movieService) searchByCursor(ctx context.Context, filter MovieFilter, scope string) (*ResultMovie, error) {
+	orders, err := search.ParseSort(filter.Sort, movieMetadata, movieSortable)
 	if err != nil {
 		return nil, err
 	}
-	var movies []Movie
-	for rows.Next() {
-		movie := Movie{}
-		err := rows.Scan(&movie.Id, &movie.Name, &movie.Watched)
-		if err != nil {
-			return nil, err
-		}
-		movies = append(movies, movie)
+	columns, err := search.Select(filter.Fields, movieMetadata, movieSortable, orders)
+	if err != nil {
+		return nil, err
 	}
-	query, params := BuildMovieCount(filter, m.BuildParam)
-	rows, err = m.DB.QueryContext(ctx, query, params...)
+	where, params := BuildMovieFilter(filter, m.repository.BuildParam)
+	where = repository.And(where, scope)
+	page := search.Page{Columns: columns, Where: where, Params: params, Sort: filter.Sort, Orders: orders, Cursor: filter.Cursor, Limit: filter.Limit}
+	var movies []Movie
+	next, prev, err := m.cursor.Query(ctx, m.repository, &movies, page)
 	if err != nil {
 		return nil, err
 	}
-	var total int64
-	for rows.Next() {
-		err := rows.Scan(&total)
-		if err != nil {
+	result := &ResultMovie{List: movies, NextCursor: next, PrevCursor: prev}
+	if !filter.SkipTotal {
+		query, params := BuildMovieCount(filter, scope, m.repository.BuildParam)
+		if result.Total, err = m.repository.Count(ctx, query, params...); err != nil {
 			return nil, err
 		}
 	}
-	return &ResultMovie{List: movies, Total: total}, nil
+	return result, nil
 }
 
-func BuildMovieCount(filter MovieFilter, buildParam func(int) string) (string, []interface{}) {
-	query := "select count* from movie"
+func BuildMovieCount(filter MovieFilter, scope string, buildParam func(int) string) (string, []interface{}) {
+	query := "select count(*) from movies"
 	where, params := BuildMovieFilter(filter, buildParam)
+	where = repository.And(where, scope)
 	if len(where) > 0 {
 		query = query + " where " + where
 	}
 	return query, params
 }
 
-func BuildMovieQuery(filter MovieFilter, buildParam func(int) string) (string, []interface{}) {
-	query := "select * from movies"
-	where, params := BuildMovieCount(filter, buildParam)
+func BuildMovieQuery(filter MovieFilter, scope string, d *dialect.Dialect) (string, []interface{}, error) {
+	var orders []search.Order
+	if len(filter.Sort) > 0 {
+		var err error
+		if orders, err = search.ParseSort(filter.Sort, movieMetadata, movieSortable); err != nil {
+			return "", nil, err
+		}
+	}
+	columns, err := search.Select(filter.Fields, movieMetadata, movieSortable, orders)
+	if err != nil {
+		return "", nil, err
+	}
+	query := fmt.Sprintf("select %s from movies", columns)
+	where, params := BuildMovieFilter(filter, d.Param)
+	where = repository.And(where, scope)
 	if len(where) > 0 {
 		query = query + " where " + where
 	}
-	return query, params
+	if len(orders) > 0 {
+		query = query + " order by " + search.OrderBy(orders, d)
+	}
+	if filter.PageSize > 0 {
+		var offset int64
+		if filter.PageIndex > 0 {
+			offset = (filter.PageIndex - 1) * filter.PageSize
+		}
+		clause, pageParams := d.Page(filter.PageSize, offset, len(orders) > 0, len(params)+1)
+		query = query + clause
+		params = append(params, pageParams...)
+	}
+	return query, params, nil
 }
 
 func BuildMovieFilter(filter MovieFilter, buildParam func(int) string) (string, []interface{}) {
-	var condition []string
-	var params []interface{}
-	i := 1
-	if len(filter.Id) > 0 {
-		params = append(params, filter.Id)
-		condition = append(condition, fmt.Sprint(`id = %s`, buildParam(i)))
-		i++
-	}
-	if len(filter.Name) > 0 {
-		q := "%" + filter.Name + "%"
-		params = append(params, q)
-		condition = append(condition, fmt.Sprintf(`name like %s`, buildParam(i)))
-		i++
-	}
-	if len(condition) > 0 {
-		return strings.Join(condition, " and "), params
-	} else {
-		return "", params
-	}
+	return search.Where(filter, movieMetadata, buildParam)
 }
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"go-service/internal/dialect"
+	. "go-service/internal/filter"
+)
+
+func TestBuildMovieQuery(t *testing.T) {
+	watched := true
+	tests := []struct {
+		name    string
+		dialect string
+		filter  MovieFilter
+		query   string
+		params  []interface{}
+	}{
+		{"whole table", dialect.MySQL, MovieFilter{}, "select * from movies where deleted_at is null", nil},
+		{"first page", dialect.MySQL, MovieFilter{PageSize: 20}, "select * from movies where deleted_at is null limit ? offset ?", []interface{}{int64(20), int64(0)}},
+		{"third page", dialect.Postgres, MovieFilter{Watched: &watched, Sort: "-name", PageIndex: 3, PageSize: 10},
+			"select * from movies where watched = $1 and deleted_at is null order by name desc nulls last, id nulls first limit $2 offset $3",
+			[]interface{}{true, int64(10), int64(20)}},
+		{"unordered page on sql server", dialect.SQLServer, MovieFilter{Name: "heat", PageIndex: 2, PageSize: 5},
+			"select * from movies where name like @p1 escape '!' and deleted_at is null order by (select null) offset @p2 rows fetch next @p3 rows only",
+			[]interface{}{"%heat%", int64(5), int64(5)}},
+		{"page index without size", dialect.MySQL, MovieFilter{PageIndex: 2}, "select * from movies where deleted_at is null", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, err := BuildMovieQuery(tt.filter, "deleted_at is null", &dialect.Dialect{Name: tt.dialect})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.query {
+				t.Fatalf("got query %s, want %s", query, tt.query)
+			}
+			if len(params) != len(tt.params) || (len(params) > 0 && !reflect.DeepEqual(params, tt.params)) {
+				t.Fatalf("got params %v, want %v", params, tt.params)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+
+	. "go-service/internal/filter"
+	. "go-service/internal/model"
+	"go-service/internal/retry"
+)
+
+type retriedUserService struct {
+	next   UserService
+	policy *retry.Policy
+}
+
+// RetryUserService runs every method of next under policy, retrying the reads on any transient error and the writes on lock conflicts.
+func RetryUserService(next UserService, policy *retry.Policy) UserService {
+	return &retriedUserService{next: next, policy: policy}
+}
+
+func (s *retriedUserService) All(ctx context.Context) ([]User, error) {
+	var users []User
+	err := s.policy.Do(ctx, "users.All", true, func(ctx context.Context) (err error) {
+		users, err = s.next.All(ctx)
+		return err
+	})
+	return users, err
+}
+
+func (s *retriedUserService) Load(ctx context.Context, id string) (*User, error) {
+	var user *User
+	err := s.policy.Do(ctx, "users.Load", true, func(ctx context.Context) (err error) {
+		user, err = s.next.Load(ctx, id)
+		return err
+	})
+	return user, err
+}
+
+func (s *retriedUserService) Insert(ctx context.Context, user *User) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "users.Insert", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Insert(ctx, user)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedUserService) Update(ctx context.Context, user *User) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "users.Update", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Update(ctx, user)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedUserService) Patch(ctx context.Context, user map[string]interface{}) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "users.Patch", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Patch(ctx, user)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedUserService) Delete(ctx context.Context, id string) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "users.Delete", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Delete(ctx, id)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedUserService) Search(ctx context.Context, filter UserFilter) (*Result, error) {
+	var result *Result
+	err := s.policy.Do(ctx, "users.Search", true, func(ctx context.Context) (err error) {
+		result, err = s.next.Search(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+func (s *retriedUserService) Trash(ctx context.Context, filter UserFilter) (*Result, error) {
+	var result *Result
+	err := s.policy.Do(ctx, "users.Trash", true, func(ctx context.Context) (err error) {
+		result, err = s.next.Trash(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+func (s *retriedUserService) Restore(ctx context.Context, id string) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "users.Restore", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Restore(ctx, id)
+		return err
+	})
+	return res, err
+}
+
+type retriedMovieService struct {
+	next   MovieService
+	policy *retry.Policy
+}
+
+func RetryMovieService(next MovieService, policy *retry.Policy) MovieService {
+	return &retriedMovieService{next: next, policy: policy}
+}
+
+func (s *retriedMovieService) All(ctx context.Context) ([]Movie, error) {
+	var movies []Movie
+	err := s.policy.Do(ctx, "movies.All", true, func(ctx context.Context) (err error) {
+		movies, err = s.next.All(ctx)
+		return err
+	})
+	return movies, err
+}
+
+func (s *retriedMovieService) Load(ctx context.Context, id string) (*Movie, error) {
+	var movie *Movie
+	err := s.policy.Do(ctx, "movies.Load", true, func(ctx context.Context) (err error) {
+		movie, err = s.next.Load(ctx, id)
+		return err
+	})
+	return movie, err
+}
+
+func (s *retriedMovieService) Insert(ctx context.Context, movie *Movie) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "movies.Insert", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Insert(ctx, movie)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedMovieService) Update(ctx context.Context, movie *Movie) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "movies.Update", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Update(ctx, movie)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedMovieService) Patch(ctx context.Context, movie map[string]interface{}) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "movies.Patch", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Patch(ctx, movie)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedMovieService) Delete(ctx context.Context, id string) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "movies.Delete", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Delete(ctx, id)
+		return err
+	})
+	return res, err
+}
+
+func (s *retriedMovieService) Search(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	var result *ResultMovie
+	err := s.policy.Do(ctx, "movies.Search", true, func(ctx context.Context) (err error) {
+		result, err = s.next.Search(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+func (s *retriedMovieService) Trash(ctx context.Context, filter MovieFilter) (*ResultMovie, error) {
+	var result *ResultMovie
+	err := s.policy.Do(ctx, "movies.Trash", true, func(ctx context.Context) (err error) {
+		result, err = s.next.Trash(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+func (s *retriedMovieService) Restore(ctx context.Context, id string) (int64, error) {
+	var res int64
+	err := s.policy.Do(ctx, "movies.Restore", false, func(ctx context.Context) (err error) {
+		res, err = s.next.Restore(ctx, id)
+		return err
+	})
+	return res, err
+}
+
+type retriedAuditService struct {
+	next   AuditService
+	policy *retry.Policy
+}
+
+func RetryAuditService(next AuditService, policy *retry.Policy) AuditService {
+	return &retriedAuditService{next: next, policy: policy}
+}
+
+func (s *retriedAuditService) Search(ctx context.Context, filter AuditFilter) (*AuditResult, error) {
+	var result *AuditResult
+	err := s.policy.Do(ctx, "audit.Search", true, func(ctx context.Context) (err error) {
+		result, err = s.next.Search(ctx, filter)
+		return err
+	})
+	return result, err
+}
+
+type retriedAuthService struct {
+	next   AuthService
+	policy *retry.Policy
+}
+
+func RetryAuthService(next AuthService, policy *retry.Policy) AuthService {
+	return &retriedAuthService{next: next, policy: policy}
+}
+
+func (s *retriedAuthService) Login(ctx context.Context, login Login) (*Token, error) {
+	var token *Token
+	err := s.policy.Do(ctx, "auth.Login", false, func(ctx context.Context) (err error) {
+		token, err = s.next.Login(ctx, login)
+		return err
+	})
+	return token, err
+}
+
+func (s *retriedAuthService) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	var token *Token
+	err := s.policy.Do(ctx, "auth.Refresh", false, func(ctx context.Context) (err error) {
+		token, err = s.next.Refresh(ctx, refreshToken)
+		return err
+	})
+	return token, err
+}
+
+func (s *retriedAuthService) Logout(ctx context.Context, refreshToken string) error {
+	return s.policy.Do(ctx, "auth.Logout", false, func(ctx context.Context) error {
+		return s.next.Logout(ctx, refreshToken)
+	})
+}
+
+func (s *retriedAuthService) ChangePassword(ctx context.Context, userId string, change PasswordChange) error {
+	return s.policy.Do(ctx, "auth.ChangePassword", false, func(ctx context.Context) error {
+		return s.next.ChangePassword(ctx, userId, change)
+	})
+}
@@ -2,14 +2,21 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	q "github.com/core-go/sql"
 	"reflect"
-	"strings"
 
+	"go-service/internal/apperror"
+	"go-service/internal/audit"
+	"go-service/internal/dialect"
 	. "go-service/internal/filter"
 	. "go-service/internal/model"
+	"go-service/internal/repository"
+	"go-service/internal/search"
+)
+
+var (
+	userMetadata = repository.GetMetadata(reflect.TypeOf(User{}))
+	userSortable = []string{"id", "username", "email", "phone", "dateOfBirth", "deletedAt"}
 )
 
 type UserService interface {
@@ -20,192 +27,179 @@ type UserService interface {
 	Patch(ctx context.Context, user map[string]interface{}) (int64, error)
 	Delete(ctx context.Context, id string) (int64, error)
 	Search(ctx context.Context, filter UserFilter) (*Result, error)
+	Trash(ctx context.Context, filter UserFilter) (*Result, error)
+	Restore(ctx context.Context, id string) (int64, error)
 }
 
 type userService struct {
-	DB *sql.DB
-	BuildParam func(int) string
+	repository *repository.Repository
+	cursor     *search.Codec
+	auditor    *audit.Auditor
 }
 
-func NewUserService(db *sql.DB) UserService {
-	buildParam := q.GetBuild(db)
-	return &userService{DB: db, BuildParam: buildParam}
+func NewUserService(repository *repository.Repository, cursor *search.Codec, auditor *audit.Auditor) UserService {
+	return &userService{repository: repository, cursor: cursor, auditor: auditor}
 }
 
 func (s *userService) All(ctx context.Context) ([]User, error) {
-	query := "select id, username, email, phone, date_of_birth from users"
-	rows, err := s.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	ctx = repository.ReadOnly(ctx)
 	var users []User
-	for rows.Next() {
-		var user User
-		err = rows.Scan(&user.Id, &user.Username, &user.Phone, &user.Email, &user.DateOfBirth)
-		if err != nil {
-			return nil, err
-		}
-		users = append(users, user)
-	}
-	return users, nil
+	err := s.repository.All(ctx, &users)
+	return users, err
 }
 
 func (s *userService) Load(ctx context.Context, id string) (*User, error) {
-	query := "select id, username, email, phone, date_of_birth from users where id = ? limit 1"
-	rows, err := s.DB.QueryContext(ctx, query, id)
+	ctx = repository.ReadOnly(ctx)
+	var user User
+	ok, err := s.repository.Load(ctx, id, &user)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var user User
-		err = rows.Scan(&user.Id, &user.Username, &user.Phone, &user.Email, &user.DateOfBirth)
-		if err != nil {
-			return nil, err
-		}
-		return &user, nil
+	if !ok {
+		return nil, apperror.Newf(apperror.NotFound, "user %s not found", id)
 	}
-	return nil, nil
+	return &user, nil
 }
 
 func (s *userService) Insert(ctx context.Context, user *User) (int64, error) {
-	query := "insert into users (id, username, email, phone, date_of_birth) values (?, ?, ?, ?, ?)"
-	stmt, er0 := s.DB.Prepare(query)
-	if er0 != nil {
-		return -1, nil
-	}
-	res, er1 := stmt.ExecContext(ctx, user.Id, user.Username, user.Email, user.Phone, user.DateOfBirth)
-	if er1 != nil {
-		return -1, nil
-	}
-	return res.RowsAffected()
+	return s.auditor.Track(ctx, s.repository, audit.Insert, user.Id, func(ctx context.Context) (int64, error) {
+		return s.repository.Insert(ctx, user)
+	})
 }
 
 func (s *userService) Update(ctx context.Context, user *User) (int64, error) {
-	query := "update users set username = ?, email = ?, phone = ?, date_of_birth = ? where id = ?"
-	stmt, er0 := s.DB.Prepare(query)
-	if er0 != nil {
-		return -1, nil
-	}
-	res, er1 := stmt.ExecContext(ctx, user.Username, user.Email, user.Phone, user.DateOfBirth, user.Id)
-	if er1 != nil {
-		return -1, er1
-	}
-	return res.RowsAffected()
+	return s.auditor.Track(ctx, s.repository, audit.Update, user.Id, func(ctx context.Context) (int64, error) {
+		return s.repository.Update(ctx, user)
+	})
 }
 
 func (s *userService) Patch(ctx context.Context, user map[string]interface{}) (int64, error) {
-	userType := reflect.TypeOf(User{})
-	jsonColumnMap := q.MakeJsonColumnMap(userType)
-	colMap := q.JSONToColumns(user, jsonColumnMap)
-	keys, _ := q.FindPrimaryKeys(userType)
-	query, args := q.BuildToPatch("users", colMap, keys, q.BuildParam)
-	res, err := s.DB.ExecContext(ctx, query, args...)
-	if err != nil {
-		return -1, err
-	}
-	return res.RowsAffected()
+	return s.auditor.Track(ctx, s.repository, audit.Patch, user["id"], func(ctx context.Context) (int64, error) {
+		return s.repository.Patch(ctx, user)
+	})
 }
 
 func (s *userService) Delete(ctx context.Context, id string) (int64, error) {
-	query := "delete from users where id = ?"
-	stmt, er0 := s.DB.Prepare(query)
-	if er0 != nil {
-		return -1, nil
-	}
-	res, er1 := stmt.ExecContext(ctx, id)
-	if er1 != nil {
-		return -1, er1
-	}
-	return res.RowsAffected()
+	return s.auditor.Track(ctx, s.repository, audit.Delete, id, func(ctx context.Context) (int64, error) {
+		return s.repository.Delete(ctx, id)
+	})
+}
+
+func (s *userService) Restore(ctx context.Context, id string) (int64, error) {
+	return s.auditor.Track(ctx, s.repository, audit.Restore, id, func(ctx context.Context) (int64, error) {
+		return s.repository.Restore(ctx, id)
+	})
 }
 
 func (s *userService) Search(ctx context.Context, filter UserFilter) (*Result, error) {
-	query, params := BuildQuery(filter, s.BuildParam)
-	rows, err := s.DB.QueryContext(ctx, query, params...)
-	if err != nil {
+	return s.search(ctx, filter, s.repository.Scope(false))
+}
+
+// Trash searches the soft deleted users with the same filters as Search.
+func (s *userService) Trash(ctx context.Context, filter UserFilter) (*Result, error) {
+	scope := s.repository.Scope(true)
+	if len(scope) == 0 {
+		return nil, apperror.New(apperror.NotFound, "soft delete is disabled for users")
+	}
+	return s.search(ctx, filter, scope)
+}
+
+func (s *userService) search(ctx context.Context, filter UserFilter, scope string) (*Result, error) {
+	ctx = repository.ReadOnly(ctx)
+	if err := search.Check(ctx, filter, filter.Sort, filter.Fields); err != nil {
 		return nil, err
 	}
+	if len(filter.Cursor) > 0 || filter.Limit > 0 {
+		return s.searchByCursor(ctx, filter, scope)
+	}
 	var users []User
-	for rows.Next() {
-		user := User{}
-		err := rows.Scan(&user.Id, &user.Username, &user.Email, &user.Phone, &user.DateOfBirth)
-		if err != nil {
+	query, params, err := BuildQuery(filter, scope, s.repository.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repository.Query(ctx, &users, query, params...); err != nil {
+		return nil, err
+	}
+	result := &Result{List: users}
+	if !filter.SkipTotal {
+		query, params = BuildCount(filter, scope, s.repository.BuildParam)
+		if result.Total, err = s.repository.Count(ctx, query, params...); err != nil {
 			return nil, err
 		}
-		users = append(users, user)
 	}
-	query, params = BuildCount(filter, s.BuildParam)
-	rows, err = s.DB.QueryContext(ctx, query, params...)
+	return result, nil
+}
+
+func (s *userService) searchByCursor(ctx context.Context, filter UserFilter, scope string) (*Result, error) {
+	orders, err := search.ParseSort(filter.Sort, userMetadata, userSortable)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := search.Select(filter.Fields, userMetadata, userSortable, orders)
 	if err != nil {
 		return nil, err
 	}
-	var total int64
-	for rows.Next() {
-		err := rows.Scan(&total)
-		if err != nil {
+	where, params := BuildFilter(filter, s.repository.BuildParam)
+	where = repository.And(where, scope)
+	page := search.Page{Columns: columns, Where: where, Params: params, Sort: filter.Sort, Orders: orders, Cursor: filter.Cursor, Limit: filter.Limit}
+	var users []User
+	next, prev, err := s.cursor.Query(ctx, s.repository, &users, page)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{List: users, NextCursor: next, PrevCursor: prev}
+	if !filter.SkipTotal {
+		query, params := BuildCount(filter, scope, s.repository.BuildParam)
+		if result.Total, err = s.repository.Count(ctx, query, params...); err != nil {
 			return nil, err
 		}
 	}
-	return &Result{List: users, Total: total}, nil
+	return result, nil
 }
 
-func BuildCount(filter UserFilter, buildParam func(int) string) (string, []interface{}) {
+func BuildCount(filter UserFilter, scope string, buildParam func(int) string) (string, []interface{}) {
 	query := "select count(*) from users"
 	where, params := BuildFilter(filter, buildParam)
+	where = repository.And(where, scope)
 	if len(where) > 0 {
 		query = query + " where " + where
 	}
 	return query, params
 }
-func BuildQuery(filter UserFilter, buildParam func(int) string) (string, []interface{}) {
-	query := "select * from users"
-	where, params := BuildFilter(filter, buildParam)
+
+func BuildQuery(filter UserFilter, scope string, d *dialect.Dialect) (string, []interface{}, error) {
+	var orders []search.Order
+	if len(filter.Sort) > 0 {
+		var err error
+		if orders, err = search.ParseSort(filter.Sort, userMetadata, userSortable); err != nil {
+			return "", nil, err
+		}
+	}
+	columns, err := search.Select(filter.Fields, userMetadata, userSortable, orders)
+	if err != nil {
+		return "", nil, err
+	}
+	query := fmt.Sprintf("select %s from users", columns)
+	where, params := BuildFilter(filter, d.Param)
+	where = repository.And(where, scope)
 	if len(where) > 0 {
 		query = query + " where " + where
 	}
+	if len(orders) > 0 {
+		query = query + " order by " + search.OrderBy(orders, d)
+	}
 	if filter.PageSize > 0 {
-		query = query + fmt.Sprintf(` limit %d`, filter.PageSize)
+		var offset int64
 		if filter.PageIndex > 0 {
-			pageIndex := (filter.PageIndex - 1) * filter.PageSize
-			query = query + fmt.Sprintf(` offset %d`, pageIndex)
+			offset = (filter.PageIndex - 1) * filter.PageSize
 		}
+		clause, pageParams := d.Page(filter.PageSize, offset, len(orders) > 0, len(params)+1)
+		query = query + clause
+		params = append(params, pageParams...)
 	}
-	return query, params
+	return query, params, nil
 }
+
 func BuildFilter(filter UserFilter, buildParam func(int) string) (string, []interface{}) {
-	var condition []string
-	var params []interface{}
-	i := 1
-
-	if len(filter.Id) > 0 {
-		params = append(params, filter.Id)
-		condition = append(condition, fmt.Sprintf(`id = %s`, buildParam(i)))
-		i++
-	}
-	if len(filter.Email) > 0 {
-		q := "%" + filter.Email + "%"
-		params = append(params, q)
-		condition = append(condition, fmt.Sprintf(`email like %s`, buildParam(i)))
-		i++
-	}
-	if len(filter.Username) > 0 {
-		q := "%" + filter.Username + "%"
-		params = append(params, q)
-		condition = append(condition, fmt.Sprintf(`username like %s`, buildParam(i)))
-		i++
-	}
-	if len(filter.Phone) > 0 {
-		q := "%" + filter.Phone + "%"
-		params = append(params, q)
-		condition = append(condition, fmt.Sprintf(`phone like %s`, buildParam(i)))
-		i++
-	}
-
-	if len(condition) > 0 {
-		return strings.Join(condition, " and "), params
-	} else {
-		return "", params
-	}
+	return search.Where(filter, userMetadata, buildParam)
 }
@@ -0,0 +1,73 @@
+package timeout
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-service/internal/auth"
+	"go-service/internal/repository"
+)
+
+type Rule struct {
+	Methods []string `mapstructure:"methods"`
+	Path    string   `mapstructure:"path"`
+	Timeout int64    `mapstructure:"timeout"`
+}
+
+func (r Rule) match(method, path string) bool {
+	if len(r.Methods) > 0 && !contains(r.Methods, method) {
+		return false
+	}
+	return auth.MatchPath(r.Path, path)
+}
+
+// Config sets the time, in milliseconds, a request may take. Rules are tried in order and the first matching
+// one wins, other routes use Default; 0 means no deadline.
+type Config struct {
+	Default int64  `mapstructure:"default"`
+	Routes  []Rule `mapstructure:"routes"`
+}
+
+// Timeouts is the router middleware turning the timeout of each route into a deadline of the request context,
+// which cancels the statements still running when it expires. The request context is also canceled when the
+// client disconnects.
+type Timeouts struct {
+	config Config
+}
+
+func NewTimeouts(c Config) *Timeouts {
+	return &Timeouts{config: c}
+}
+
+func (t *Timeouts) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := t.timeout(r.Method, r.URL.Path)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(repository.WithStatementTimeout(ctx, timeout)))
+	})
+}
+
+func (t *Timeouts) timeout(method, path string) time.Duration {
+	for _, rule := range t.config.Routes {
+		if rule.match(method, path) {
+			return time.Duration(rule.Timeout) * time.Millisecond
+		}
+	}
+	return time.Duration(t.config.Default) * time.Millisecond
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
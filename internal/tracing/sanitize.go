@@ -0,0 +1,56 @@
+package tracing
+
+import "strings"
+
+const maxStatement = 2048
+
+// Sanitize replaces the string and number literals of query with "?" and collapses its whitespace,
+// so that statements recorded in spans carry no data.
+func Sanitize(query string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					i++
+				} else if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			b.WriteByte('?')
+		case isDigit(c) && (i == 0 || !isWord(query[i-1])):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		default:
+			b.WriteByte(c)
+		}
+		space = false
+		if b.Len() >= maxStatement {
+			break
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || c == '`' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
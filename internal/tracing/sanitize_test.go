@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"string", "select * from users where id = 'ironman'", "select * from users where id = ?"},
+		{"doubled quote", "select * from users where name = 'O''Brien' and id = ?", "select * from users where name = ? and id = ?"},
+		{"escaped quote", `select * from users where name = 'O\'Brien' and id = ?`, "select * from users where name = ? and id = ?"},
+		{"escaped backslash", `select * from users where name = 'a\\' and id = 'b'`, "select * from users where name = ? and id = ?"},
+		{"unterminated string", "select * from users where id = 'iron", "select * from users where id = ?"},
+		{"numbers", "select * from movies where year > 1999 and rating >= 7.5 limit 10", "select * from movies where year > ? and rating >= ? limit ?"},
+		{"number first", "1", "?"},
+		{"identifiers keep digits", "select t1.id from users t1 where t1.id = $1 or t1.id = @p2 or `col2` = ?", "select t1.id from users t1 where t1.id = $1 or t1.id = @p2 or `col2` = ?"},
+		{"whitespace", "  select *\n\tfrom   users\r\n where id = ?  ", "select * from users where id = ?"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.query); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeTruncates(t *testing.T) {
+	query := "select * from users where id in (" + strings.Repeat("'x', ", 2000) + "'x')"
+	got := Sanitize(query)
+	if len(got) != maxStatement || !strings.HasPrefix(got, "select * from users where id in (?, ?, ") {
+		t.Fatalf("got %d bytes: %.60q", len(got), got)
+	}
+}
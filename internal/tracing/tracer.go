@@ -0,0 +1,229 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+
+	// TraceId and SpanId are the context keys core-go/log reads when they are listed in log.fields.
+	TraceId = "traceId"
+	SpanId  = "spanId"
+
+	instrumentation = "go-service"
+)
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter is "otlp" to send spans to a collector over HTTP, "stdout", or "file" to write them as JSON.
+	Exporter string            `mapstructure:"exporter"`
+	Endpoint string            `mapstructure:"endpoint"`
+	Insecure bool              `mapstructure:"insecure"`
+	Headers  map[string]string `mapstructure:"headers"`
+	File     string            `mapstructure:"file"`
+	// SampleRatio is the share of new traces recorded; incoming sampled traces are always followed.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Tracer owns the tracer provider, which it also installs as the global one with the W3C trace context propagator.
+type Tracer struct {
+	enabled    bool
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	closer     io.Closer
+}
+
+func NewTracer(ctx context.Context, c Config, serviceName string) (*Tracer, error) {
+	t := &Tracer{
+		enabled:    c.Enabled,
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	otel.SetTextMapPropagator(t.propagator)
+	if !c.Enabled {
+		t.tracer = trace.NewNoopTracerProvider().Tracer(instrumentation)
+		return t, nil
+	}
+	exporter, err := t.exporter(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	ratio := c.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(t.provider)
+	t.tracer = t.provider.Tracer(instrumentation)
+	return t, nil
+}
+
+func (t *Tracer) exporter(ctx context.Context, c Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(c.Exporter) {
+	case "", ExporterOTLP:
+		var options []otlptracehttp.Option
+		if len(c.Endpoint) > 0 {
+			options = append(options, otlptracehttp.WithEndpoint(c.Endpoint))
+		}
+		if c.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		if len(c.Headers) > 0 {
+			options = append(options, otlptracehttp.WithHeaders(c.Headers))
+		}
+		return otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if len(c.File) == 0 {
+			return nil, fmt.Errorf("tracing.file is required by the file exporter")
+		}
+		f, err := os.OpenFile(c.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		t.closer = f
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	}
+	return nil, fmt.Errorf("unsupported tracing exporter %s, use otlp, stdout or file", c.Exporter)
+}
+
+// Shutdown flushes the spans not exported yet.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	err := t.provider.Shutdown(ctx)
+	if t.closer != nil {
+		t.closer.Close()
+	}
+	return err
+}
+
+// Handler is the router middleware that continues the caller's trace from its traceparent header, or starts one,
+// and runs the request in a server span named after the route template. The response carries the traceparent too.
+func (t *Tracer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !t.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		ctx, span := t.tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(r.URL.RequestURI()),
+				semconv.HTTPClientIPKey.String(clientIP(r.RemoteAddr)),
+			))
+		defer span.End()
+		sc := span.SpanContext()
+		ctx = context.WithValue(ctx, TraceId, sc.TraceID().String())
+		ctx = context.WithValue(ctx, SpanId, sc.SpanID().String())
+		t.propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(sw.status))
+		}
+	})
+}
+
+// Transport propagates the trace of the request context to outgoing calls, in a client span per call.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(instrumentation).Start(r.Context(), r.Method+" "+r.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method), semconv.HTTPURLKey.String(r.URL.String())))
+	defer span.End()
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	res, err := t.base.RoundTrip(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return res, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, strconv.Itoa(res.StatusCode))
+	}
+	return res, nil
+}
+
+func clientIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
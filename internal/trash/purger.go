@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/core-go/log"
+
+	"go-service/internal/repository"
+)
+
+const DefaultBatchSize = 1000
+
+type Entity struct {
+	Enabled   bool  `mapstructure:"enabled"`
+	Retention int64 `mapstructure:"retention"`
+}
+
+// Config turns soft delete on per entity. Retention is in days, Interval in seconds; 0 keeps trashed rows forever.
+type Config struct {
+	Interval  int64             `mapstructure:"interval"`
+	BatchSize int               `mapstructure:"batch_size"`
+	Entities  map[string]Entity `mapstructure:"entities"`
+}
+
+type Purger struct {
+	Repositories map[string]*repository.Repository
+	Config       Config
+}
+
+func NewPurger(config Config, repositories map[string]*repository.Repository) *Purger {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	return &Purger{Repositories: repositories, Config: config}
+}
+
+// Purge hard deletes the rows of each entity that stayed in the trash longer than its retention,
+// in batches so that no single statement holds locks on the whole table.
+func (p *Purger) Purge(ctx context.Context) (map[string]int64, error) {
+	names := make([]string, 0, len(p.Repositories))
+	for name := range p.Repositories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	purged := make(map[string]int64)
+	for _, name := range names {
+		entity := p.Config.Entities[name]
+		if !entity.Enabled || entity.Retention <= 0 {
+			continue
+		}
+		before := time.Now().Add(-time.Duration(entity.Retention) * 24 * time.Hour)
+		for {
+			affected, err := p.Repositories[name].Purge(ctx, before, p.Config.BatchSize)
+			if err != nil {
+				return purged, err
+			}
+			purged[name] += affected
+			if affected < int64(p.Config.BatchSize) {
+				break
+			}
+		}
+	}
+	return purged, nil
+}
+
+// Run purges every Interval seconds until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	if p.Config.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(p.Config.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := p.Purge(ctx)
+			for name, count := range purged {
+				if count > 0 {
+					log.Info(ctx, fmt.Sprintf("purged %d rows from %s trash", count, name))
+				}
+			}
+			if err != nil {
+				log.Error(ctx, fmt.Sprintf("purge of trash failed: %v", err))
+			}
+		}
+	}
+}
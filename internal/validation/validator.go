@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"go-service/internal/apperror"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,}$`)
+	namePattern     = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} .,'&:!?()-]*$`)
+	phonePattern    = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+)
+
+// Validator enforces the `validate` struct tags on models and filters, including the custom
+// username, name and phone rules, and reports failures as apperror.Validation with json field names.
+type Validator struct {
+	validate *validator.Validate
+}
+
+func NewValidator() *Validator {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonName)
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("name", func(fl validator.FieldLevel) bool {
+		return namePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(phoneSeparators.Replace(fl.Field().String()))
+	})
+	return &Validator{validate: v}
+}
+
+func (v *Validator) Validate(ctx context.Context, model interface{}) error {
+	return toError(v.validate.StructCtx(ctx, model))
+}
+
+// ValidatePartial checks only the fields whose json names are listed, as sent in a PATCH body.
+func (v *Validator) ValidatePartial(ctx context.Context, model interface{}, jsonFields []string) error {
+	modelType := reflect.Indirect(reflect.ValueOf(model)).Type()
+	var fields []string
+	for _, name := range jsonFields {
+		if f, ok := fieldByJson(modelType, name); ok {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return toError(v.validate.StructPartialCtx(ctx, model, fields...))
+}
+
+func toError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	fields := make([]apperror.FieldError, len(errs))
+	for i, e := range errs {
+		fields[i] = apperror.FieldError{Field: e.Field(), Code: e.Tag(), Param: e.Param(), Message: message(e)}
+	}
+	return apperror.NewValidation(fields)
+}
+
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", e.Field())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", e.Field(), e.Param())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", e.Field(), e.Param())
+	case "email", "username", "name", "phone":
+		return fmt.Sprintf("%s is not a valid %s", e.Field(), e.Tag())
+	default:
+		return fmt.Sprintf("%s failed on %s", e.Field(), e.Tag())
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if len(name) == 0 {
+		return f.Name
+	}
+	return name
+}
+
+func fieldByJson(modelType reflect.Type, name string) (string, bool) {
+	for i := 0; i < modelType.NumField(); i++ {
+		f := modelType.Field(i)
+		if jsonName(f) == name {
+			return f.Name, true
+		}
+	}
+	return "", false
+}
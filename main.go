@@ -8,20 +8,42 @@ import (
 	mid "github.com/core-go/log/middleware"
 	sv "github.com/core-go/service"
 	"github.com/gorilla/mux"
+	"os"
 
 	"go-service/internal/app"
+	"go-service/internal/lifecycle"
+	"go-service/internal/middleware"
+	"go-service/internal/tracing"
 )
 
 func main() {
 	var conf app.Config
-	er1 := config.Load(&conf, "configs/config")
+	er1 := config.Load(&conf, "configs/config", "configs/policy")
 	if er1 != nil {
 		panic(er1)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.Migrate(context.Background(), conf, os.Args[2:]); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	lc := lifecycle.New(conf.Shutdown)
+	tracer, er2 := tracing.NewTracer(ctx, conf.Tracing, conf.Server.Name)
+	if er2 != nil {
+		panic(er2)
+	}
+	lc.Append(lifecycle.Hook{Name: "tracing", OnStop: tracer.Shutdown})
+
 	r := mux.NewRouter()
 
 	log.Initialize(conf.Log)
+	r.Use(middleware.RequestIdHandler)
+	r.Use(tracer.Handler)
 	r.Use(mid.BuildContext)
 	logger := mid.NewLogger()
 	if log.IsInfoEnable() {
@@ -29,13 +51,14 @@ func main() {
 	}
 	r.Use(mid.Recover(log.PanicMsg))
 
-	er2 := app.Route(r, context.Background(), conf)
-	if er2 != nil {
-		panic(er2)
+	er3 := app.Route(r, ctx, conf, lc)
+	if er3 != nil {
+		panic(er3)
 	}
 	fmt.Println(sv.ServerInfo(conf.Server))
 	server := sv.CreateServer(conf.Server, r)
-	if er3 := server.ListenAndServe(); er3 != nil {
-		fmt.Println(er3.Error())
+	if er4 := lc.Run(ctx, server); er4 != nil {
+		fmt.Println(er4.Error())
+		os.Exit(1)
 	}
 }
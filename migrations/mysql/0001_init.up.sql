@@ -0,0 +1,19 @@
+create table if not exists users (
+  id varchar(40) not null,
+  username varchar(120),
+  email varchar(120),
+  phone varchar(45),
+  date_of_birth date,
+  primary key (id)
+);
+
+create table if not exists movies (
+  id varchar(40) not null,
+  name varchar(120),
+  watched tinyint,
+  primary key (id)
+);
+
+insert ignore into users (id, username, email, phone, date_of_birth) values ('ironman', 'tony.stark', 'tony.stark@gmail.com', '0987654321', '1963-03-25');
+insert ignore into users (id, username, email, phone, date_of_birth) values ('spiderman', 'peter.parker', 'peter.parker@gmail.com', '0987654321', '1962-08-25');
+insert ignore into users (id, username, email, phone, date_of_birth) values ('wolverine', 'james.howlett', 'james.howlett@gmail.com', '0987654321', '1974-11-16');
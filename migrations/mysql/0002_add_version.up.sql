@@ -0,0 +1,2 @@
+alter table users add column version int not null default 1;
+alter table movies add column version int not null default 1;
@@ -0,0 +1,12 @@
+create table if not exists audit_logs (
+  id bigint not null auto_increment,
+  entity varchar(40) not null,
+  entity_id varchar(255) not null,
+  operation varchar(20) not null,
+  actor varchar(120),
+  request_id varchar(128),
+  changes text not null,
+  created_at datetime(6) not null,
+  primary key (id),
+  key idx_audit_logs_entity (entity, entity_id, id)
+);
@@ -0,0 +1,7 @@
+create table if not exists rate_limits (
+  bucket varchar(255) not null,
+  tokens double not null,
+  updated_at datetime(6) not null,
+  primary key (bucket),
+  key idx_rate_limits_updated_at (updated_at)
+);
@@ -0,0 +1 @@
+drop index idx_users_username on users;
@@ -0,0 +1,2 @@
+alter table movies drop column version;
+alter table users drop column version;
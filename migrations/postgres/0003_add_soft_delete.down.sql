@@ -0,0 +1,5 @@
+drop index if exists idx_movies_deleted_at;
+drop index if exists idx_users_deleted_at;
+
+alter table movies drop column deleted_by, drop column deleted_at;
+alter table users drop column deleted_by, drop column deleted_at;
@@ -0,0 +1,13 @@
+create table if not exists audit_logs (
+  id bigserial not null,
+  entity varchar(40) not null,
+  entity_id varchar(255) not null,
+  operation varchar(20) not null,
+  actor varchar(120),
+  request_id varchar(128),
+  changes text not null,
+  created_at timestamp(6) not null,
+  primary key (id)
+);
+
+create index if not exists idx_audit_logs_entity on audit_logs (entity, entity_id, id);
@@ -0,0 +1,25 @@
+create table if not exists user_credentials (
+  user_id varchar(40) not null,
+  password_hash varchar(255) not null,
+  roles varchar(255),
+  failed_attempts int not null default 0,
+  locked_until timestamp,
+  updated_at timestamp not null,
+  primary key (user_id),
+  constraint fk_user_credentials_user foreign key (user_id) references users (id) on delete cascade
+);
+
+create table if not exists refresh_tokens (
+  id char(64) not null,
+  user_id varchar(40) not null,
+  family char(64) not null,
+  expires_at timestamp not null,
+  created_at timestamp not null,
+  revoked_at timestamp,
+  replaced_by char(64),
+  primary key (id),
+  constraint fk_refresh_tokens_user foreign key (user_id) references users (id) on delete cascade
+);
+
+create index if not exists idx_refresh_tokens_user on refresh_tokens (user_id);
+create index if not exists idx_refresh_tokens_family on refresh_tokens (family);
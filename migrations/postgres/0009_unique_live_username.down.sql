@@ -0,0 +1,2 @@
+drop index if exists idx_users_username;
+create unique index idx_users_username on users (username);
@@ -0,0 +1,2 @@
+drop table if exists movies;
+drop table if exists users;
@@ -0,0 +1,7 @@
+alter table users add column deleted_at datetime null;
+alter table users add column deleted_by varchar(120) null;
+alter table movies add column deleted_at datetime null;
+alter table movies add column deleted_by varchar(120) null;
+
+create index idx_users_deleted_at on users (deleted_at);
+create index idx_movies_deleted_at on movies (deleted_at);
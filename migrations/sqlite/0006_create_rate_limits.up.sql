@@ -0,0 +1,8 @@
+create table if not exists rate_limits (
+  bucket varchar(255) not null,
+  tokens real not null,
+  updated_at datetime not null,
+  primary key (bucket)
+);
+
+create index if not exists idx_rate_limits_updated_at on rate_limits (updated_at);
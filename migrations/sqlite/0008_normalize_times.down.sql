@@ -0,0 +1 @@
+update users set date_of_birth = substr(date_of_birth, 1, 10) where date_of_birth like '____-__-__ 00:00:00+00:00';
@@ -0,0 +1,4 @@
+update users set date_of_birth = date_of_birth || ' 00:00:00+00:00' where length(date_of_birth) = 10;
+update users set date_of_birth = substr(date_of_birth, 1, instr(date_of_birth, ' +0000 UTC') - 1) || '+00:00' where instr(date_of_birth, ' +0000 UTC') > 0;
+update users set deleted_at = substr(deleted_at, 1, instr(deleted_at, ' +0000 UTC') - 1) || '+00:00' where instr(deleted_at, ' +0000 UTC') > 0;
+update movies set deleted_at = substr(deleted_at, 1, instr(deleted_at, ' +0000 UTC') - 1) || '+00:00' where instr(deleted_at, ' +0000 UTC') > 0;
@@ -0,0 +1,19 @@
+if object_id('users', 'U') is null create table users (
+  id varchar(40) not null,
+  username varchar(120),
+  email varchar(120),
+  phone varchar(45),
+  date_of_birth date,
+  primary key (id)
+);
+
+if object_id('movies', 'U') is null create table movies (
+  id varchar(40) not null,
+  name varchar(120),
+  watched bit,
+  primary key (id)
+);
+
+insert into users (id, username, email, phone, date_of_birth) select 'ironman', 'tony.stark', 'tony.stark@gmail.com', '0987654321', '1963-03-25' where not exists (select 1 from users where id = 'ironman');
+insert into users (id, username, email, phone, date_of_birth) select 'spiderman', 'peter.parker', 'peter.parker@gmail.com', '0987654321', '1962-08-25' where not exists (select 1 from users where id = 'spiderman');
+insert into users (id, username, email, phone, date_of_birth) select 'wolverine', 'james.howlett', 'james.howlett@gmail.com', '0987654321', '1974-11-16' where not exists (select 1 from users where id = 'wolverine');
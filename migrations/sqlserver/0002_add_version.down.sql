@@ -0,0 +1,4 @@
+alter table movies drop constraint df_movies_version;
+alter table movies drop column version;
+alter table users drop constraint df_users_version;
+alter table users drop column version;
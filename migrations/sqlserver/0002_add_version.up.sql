@@ -0,0 +1,2 @@
+alter table users add version int not null constraint df_users_version default 1;
+alter table movies add version int not null constraint df_movies_version default 1;
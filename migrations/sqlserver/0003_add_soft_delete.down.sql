@@ -0,0 +1,5 @@
+drop index if exists idx_movies_deleted_at on movies;
+drop index if exists idx_users_deleted_at on users;
+
+alter table movies drop column deleted_by, deleted_at;
+alter table users drop column deleted_by, deleted_at;
@@ -0,0 +1,12 @@
+if object_id('audit_logs', 'U') is null create table audit_logs (
+  id bigint identity(1, 1) not null,
+  entity varchar(40) not null,
+  entity_id varchar(255) not null,
+  operation varchar(20) not null,
+  actor varchar(120),
+  request_id varchar(128),
+  changes nvarchar(max) not null,
+  created_at datetime2(6) not null,
+  primary key (id),
+  index idx_audit_logs_entity (entity, entity_id, id)
+);
@@ -0,0 +1,24 @@
+if object_id('user_credentials', 'U') is null create table user_credentials (
+  user_id varchar(40) not null,
+  password_hash varchar(255) not null,
+  roles varchar(255),
+  failed_attempts int not null default 0,
+  locked_until datetime2,
+  updated_at datetime2 not null,
+  primary key (user_id),
+  constraint fk_user_credentials_user foreign key (user_id) references users (id) on delete cascade
+);
+
+if object_id('refresh_tokens', 'U') is null create table refresh_tokens (
+  id char(64) not null,
+  user_id varchar(40) not null,
+  family char(64) not null,
+  expires_at datetime2 not null,
+  created_at datetime2 not null,
+  revoked_at datetime2,
+  replaced_by char(64),
+  primary key (id),
+  index idx_refresh_tokens_user (user_id),
+  index idx_refresh_tokens_family (family),
+  constraint fk_refresh_tokens_user foreign key (user_id) references users (id) on delete cascade
+);
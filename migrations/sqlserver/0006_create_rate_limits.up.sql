@@ -0,0 +1,7 @@
+if object_id('rate_limits', 'U') is null create table rate_limits (
+  bucket varchar(255) not null,
+  tokens float not null,
+  updated_at datetime2(6) not null,
+  primary key (bucket),
+  index idx_rate_limits_updated_at (updated_at)
+);
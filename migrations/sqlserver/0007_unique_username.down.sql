@@ -0,0 +1 @@
+drop index if exists idx_users_username on users;
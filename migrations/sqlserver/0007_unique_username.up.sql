@@ -0,0 +1 @@
+create unique index idx_users_username on users (username) where username is not null;
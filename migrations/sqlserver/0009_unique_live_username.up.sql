@@ -0,0 +1,2 @@
+drop index if exists idx_users_username on users;
+create unique index idx_users_username on users (username) where username is not null and deleted_at is null;